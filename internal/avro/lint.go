@@ -0,0 +1,187 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// modifiedOnField is the timestamp field the ids and content of each time window are read by
+const modifiedOnField = "modified_on"
+
+// xjoinTypes are the values of xjoin.type the records are parsed as
+var xjoinTypes = []string{"string", "date_nanos", "date", "json", "boolean", "byte", "short", "integer", "long",
+	"float", "half_float", "double", "scaled_float", "ip", "binary", "array", "reference"}
+
+var timestampTypes = []string{"date_nanos", "date"}
+
+// LintError is a problem of a full avro schema that prevents its records from being validated
+type LintError struct {
+	Field   string
+	Message string
+}
+
+func (e LintError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Lint parses the full avro schema and returns each problem that prevents it from being validated.
+// countWindowField is the timestamp field of COUNT_WINDOW_FIELD, which is only checked when it is set.
+func (s *SchemaParser) Lint(countWindowField string) (lintErrors []LintError) {
+	parsedSchema, err := s.parse()
+	if err != nil {
+		return []LintError{{Message: "unable to parse the schema: " + err.Error()}}
+	}
+
+	if len(strings.Split(parsedSchema.FullAvroSchema.Namespace, ".")) < 2 {
+		lintErrors = append(lintErrors, LintError{Field: "namespace", Message: fmt.Sprintf(
+			"%q is not a '.' delimited string including the datasource name, e.g. xjoinindexpipeline.hosts.1",
+			parsedSchema.FullAvroSchema.Namespace)})
+	}
+
+	lintErrors = append(lintErrors, lintPrimaryKey(parsedSchema)...)
+	for _, field := range parsedSchema.Fields {
+		lintErrors = append(lintErrors, lintType(parsedSchema.RootNode+"."+field.Name, field.Type)...)
+	}
+	lintErrors = append(lintErrors, lintTransformations(parsedSchema)...)
+
+	lintErrors = append(lintErrors, lintTimestamp(parsedSchema, modifiedOnField)...)
+	if countWindowField != "" && countWindowField != modifiedOnField {
+		lintErrors = append(lintErrors, lintTimestamp(parsedSchema, countWindowField)...)
+	}
+
+	return lintErrors
+}
+
+// lintPrimaryKey checks the root node has a single field with xjoin.primary.key
+func lintPrimaryKey(parsedSchema ParsedAvroSchema) []LintError {
+	var primaryKeys []string
+	for _, field := range parsedSchema.Fields {
+		if field.Type.PrimaryKey {
+			primaryKeys = append(primaryKeys, field.Name)
+		}
+	}
+
+	switch len(primaryKeys) {
+	case 1:
+		return nil
+	case 0:
+		return []LintError{{Field: parsedSchema.RootNode, Message: `no field is the primary key, ` +
+			`set "xjoin.primary.key": true on the type of the id field`}}
+	default:
+		return []LintError{{Field: parsedSchema.RootNode, Message: fmt.Sprintf(
+			`fields %s are each a primary key, set "xjoin.primary.key" on only one of them`,
+			strings.Join(primaryKeys, ", "))}}
+	}
+}
+
+// lintType checks the xjoin.type and the union shape of a type and each type nested in it
+func lintType(path string, fieldType Type) (lintErrors []LintError) {
+	if fieldType.XJoinType != "" && !slices.Contains(xjoinTypes, fieldType.XJoinType) {
+		lintErrors = append(lintErrors, LintError{Field: path, Message: fmt.Sprintf(
+			"unknown xjoin.type %q, expected one of %s", fieldType.XJoinType, strings.Join(xjoinTypes, ", "))})
+	}
+
+	switch fieldType.Type {
+	case "union":
+		lintErrors = append(lintErrors, unionError(path, fieldType))
+	case "record":
+		for _, field := range fieldType.Fields {
+			lintErrors = append(lintErrors, lintType(path+"."+field.Name, field.Type)...)
+		}
+	case "array":
+		lintErrors = append(lintErrors, lintType(path+"[]", *fieldType.Items)...)
+	case "map":
+		lintErrors = append(lintErrors, lintType(path+"{}", *fieldType.Values)...)
+	}
+	return lintErrors
+}
+
+func unionError(path string, unionType Type) LintError {
+	var branches []string
+	for _, branch := range unionType.Branches {
+		branches = append(branches, branch.Type)
+	}
+	return LintError{Field: path, Message: fmt.Sprintf(
+		"unsupported union of %s, a union can only contain a single type and null", strings.Join(branches, ", "))}
+}
+
+// findUnion returns the error of the first union of more than one type that is not null in fieldType
+func findUnion(path string, fieldType Type) (LintError, bool) {
+	switch fieldType.Type {
+	case "union":
+		return unionError(path, fieldType), true
+	case "record":
+		for _, field := range fieldType.Fields {
+			if lintError, ok := findUnion(path+"."+field.Name, field.Type); ok {
+				return lintError, true
+			}
+		}
+	case "array":
+		return findUnion(path+"[]", *fieldType.Items)
+	case "map":
+		return findUnion(path+"{}", *fieldType.Values)
+	}
+	return LintError{}, false
+}
+
+// lintTransformations checks the input and output field of each transformation is a field of the schema
+func lintTransformations(parsedSchema ParsedAvroSchema) (lintErrors []LintError) {
+	for i, transformation := range parsedSchema.FullAvroSchema.Transformations {
+		path := fmt.Sprintf("xjoin.transformations[%d]", i)
+		if _, ok := findField(parsedSchema, transformation.InputField); !ok {
+			lintErrors = append(lintErrors, LintError{Field: path, Message: fmt.Sprintf(
+				"input.field %q is not a field of the schema", transformation.InputField)})
+		}
+		if _, ok := findField(parsedSchema, transformation.OutputField); !ok {
+			lintErrors = append(lintErrors, LintError{Field: path, Message: fmt.Sprintf(
+				"output.field %q is not a field of the schema, add it to the fields of %s",
+				transformation.OutputField, parsedSchema.RootNode)})
+		}
+	}
+	return lintErrors
+}
+
+// lintTimestamp checks the field is a top level timestamp field read from the database
+func lintTimestamp(parsedSchema ParsedAvroSchema, name string) []LintError {
+	path := parsedSchema.RootNode + "." + name
+	field, ok := findField(parsedSchema, path)
+	switch {
+	case !ok || !slices.Contains(parsedSchema.DatabaseColumns, name):
+		return []LintError{{Field: path, Message: fmt.Sprintf(
+			"the field is missing, the records are compared in time windows of a top level %s field",
+			strings.Join(timestampTypes, " or "))}}
+	case !slices.Contains(timestampTypes, field.XJoinType):
+		return []LintError{{Field: path, Message: fmt.Sprintf(
+			"xjoin.type %q cannot be used for time windows, expected %s",
+			field.XJoinType, strings.Join(timestampTypes, " or "))}}
+	}
+	return nil
+}
+
+// findField returns the type of a field by its path from the root node, e.g. host.tags
+func findField(parsedSchema ParsedAvroSchema, path string) (fieldType Type, ok bool) {
+	names := strings.Split(path, ".")
+	if names[0] != parsedSchema.RootNode || len(names) < 2 {
+		return fieldType, false
+	}
+
+	fieldType = Type{Type: "record", Fields: parsedSchema.Fields}
+	for _, name := range names[1:] {
+		ok = false
+		for _, field := range fieldType.Fields {
+			if field.Name == name {
+				fieldType, ok = field.Type, true
+				break
+			}
+		}
+		if !ok {
+			return fieldType, false
+		}
+	}
+	return fieldType, true
+}
@@ -0,0 +1,144 @@
+package avro_test
+
+import (
+	"strings"
+
+	. "github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const valueField = `{"name": "value", "type": ["null", {"type": "string", "xjoin.type": "string"}, {"type": "long", "xjoin.type": "long"}]},`
+
+const invalidSchema = `{
+	"type": "record",
+	"name": "Value",
+	"namespace": "hosts",
+	"fields": [{
+		"name": "host",
+		"type": {
+			"type": "record",
+			"name": "Host",
+			"fields": [
+				{"name": "id", "type": {"type": "string", "xjoin.type": "string", "xjoin.primary.key": true}},
+				{"name": "account", "type": {"type": "string", "xjoin.type": "string", "xjoin.primary.key": true}},
+				{"name": "system", "type": {"type": "record", "name": "System", "xjoin.type": "json", "fields": [
+					{"name": "cores", "type": {"type": "int", "xjoin.type": "number"}}
+				]}},
+				` + valueField + `
+				{"name": "modified_on", "type": {"type": "string", "xjoin.type": "string"}}
+			]
+		}
+	}],
+	"xjoin.transformations": [
+		{"transformation": "object_to_array_of_strings", "input.field": "host.tags", "output.field": "host.tags_string"}
+	]
+}`
+
+var _ = Describe("Lint", func() {
+	It("should not report problems of a valid schema", func() {
+		schemaParser := SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/full")}
+		Expect(schemaParser.Lint("created_on")).To(BeEmpty())
+	})
+
+	It("should find a UUID primary key by its connect.name", func() {
+		schemaParser := SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/full")}
+		parsedSchema, err := schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedSchema.PrimaryKeyIsUUID()).To(BeTrue())
+
+		schemaParser = SchemaParser{FullSchemaString: strings.Replace(invalidSchema, valueField, "", 1)}
+		parsedSchema, err = schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedSchema.PrimaryKeyIsUUID()).To(BeFalse())
+	})
+
+	It("should resolve the named types by their short and full name", func() {
+		schemaParser := SchemaParser{FullSchemaString: `{
+			"type": "record",
+			"name": "Value",
+			"namespace": "xjoin.hosts.1",
+			"fields": [{
+				"name": "host",
+				"type": {
+					"type": "record",
+					"name": "Host",
+					"fields": [
+						{"name": "id", "type": {"type": "string", "xjoin.type": "string", "xjoin.primary.key": true}},
+						{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["a", "b"]}},
+						{"name": "owner", "type": {"type": "record", "name": "Owner", "namespace": "owners", "fields": [
+							{"name": "kind", "type": {"type": "fixed", "name": "Kind", "size": 4}},
+							{"name": "previous_kind", "type": "Kind"}
+						]}},
+						{"name": "previous_kind", "type": ["null", "Kind"]},
+						{"name": "full_kind", "type": "xjoin.hosts.1.Kind"},
+						{"name": "owner_kind", "type": "owners.Kind"},
+						{"name": "previous_owner", "type": ["null", "owners.Owner"]}
+					]
+				}
+			}]
+		}`}
+		parsedSchema, err := schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+
+		types := make(map[string]string)
+		for _, field := range parsedSchema.Fields {
+			types[field.Name] = field.Type.Type
+		}
+		Expect(types).To(Equal(map[string]string{
+			"id":             "string",
+			"kind":           "enum",
+			"owner":          "record",
+			"previous_kind":  "enum",
+			"full_kind":      "enum",
+			"owner_kind":     "fixed",
+			"previous_owner": "record",
+		}))
+		Expect(parsedSchema.Fields[2].Type.Fields[1].Type.Type).To(Equal("fixed"))
+	})
+
+	It("should reject a union of more than one type when parsing", func() {
+		schemaParser := SchemaParser{FullSchemaString: invalidSchema}
+		_, err := schemaParser.Parse()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(
+			"host.value: unsupported union of string, long, a union can only contain a single type and null"))
+	})
+
+	It("should report each problem of an invalid schema", func() {
+		schemaParser := SchemaParser{FullSchemaString: invalidSchema}
+		lintErrors := schemaParser.Lint("created_on")
+
+		var fields []string
+		for _, lintError := range lintErrors {
+			fields = append(fields, lintError.Field)
+		}
+		Expect(fields).To(Equal([]string{
+			"namespace",
+			"host",
+			"host.system.cores",
+			"host.value",
+			"xjoin.transformations[0]",
+			"xjoin.transformations[0]",
+			"host.modified_on",
+			"host.created_on",
+		}))
+
+		Expect(lintErrors[1].Error()).To(Equal(
+			`host: fields id, account are each a primary key, set "xjoin.primary.key" on only one of them`))
+		Expect(lintErrors[3].Error()).To(Equal(
+			"host.value: unsupported union of string, long, a union can only contain a single type and null"))
+		Expect(lintErrors[4].Error()).To(Equal(
+			`xjoin.transformations[0]: input.field "host.tags" is not a field of the schema`))
+		Expect(lintErrors[6].Error()).To(Equal(
+			`host.modified_on: xjoin.type "string" cannot be used for time windows, expected date_nanos or date`))
+	})
+
+	It("should report a schema that cannot be parsed", func() {
+		schemaParser := SchemaParser{FullSchemaString: `{"type": "record", "fields": []}`}
+		lintErrors := schemaParser.Lint("")
+		Expect(lintErrors).To(HaveLen(1))
+		Expect(lintErrors[0].Error()).To(HavePrefix("unable to parse the schema: root field missing"))
+	})
+})
@@ -16,10 +16,29 @@ type ParsedAvroSchema struct {
 	DatabaseColumns   []string
 	FullAvroSchema    avro.Schema
 	RootNode          string
+	Fields            []Field //resolved fields of the root node record
 	TransformedFields []string
 }
 
+// Parse parses the full avro schema. A union of more than one type that is not null is rejected, as its values
+// cannot be matched to a branch when the records are compared.
 func (s *SchemaParser) Parse() (parsedSchema ParsedAvroSchema, err error) {
+	parsedSchema, err = s.parse()
+	if err != nil {
+		return parsedSchema, errors.Wrap(err, 0)
+	}
+
+	for _, field := range parsedSchema.Fields {
+		if unionError, ok := findUnion(parsedSchema.RootNode+"."+field.Name, field.Type); ok {
+			return parsedSchema, errors.Wrap(unionError, 0)
+		}
+	}
+
+	return
+}
+
+// parse parses the full avro schema without rejecting the unions, so Lint can report them with the other problems
+func (s *SchemaParser) parse() (parsedSchema ParsedAvroSchema, err error) {
 	//unmarshal full avro schema
 	var fullAvroSchema avro.Schema
 	err = json.Unmarshal([]byte(s.FullSchemaString), &fullAvroSchema)
@@ -35,21 +54,31 @@ func (s *SchemaParser) Parse() (parsedSchema ParsedAvroSchema, err error) {
 	}
 	parsedSchema.RootNode = parsedSchema.FullAvroSchema.Fields[0].Name
 
+	//resolve the nested types of the root node, which can be a nullable union of a record
+	schemaType, err := resolveSchema(s.FullSchemaString)
+	if err != nil {
+		return parsedSchema, errors.Wrap(err, 0)
+	}
+	rootType := schemaType.Fields[0].Type
+	if rootType.Type != "record" {
+		return parsedSchema, errors.Wrap(errors.New("root field of FullAvroSchema is not a record: "+rootType.Type), 0)
+	}
+	parsedSchema.Fields = rootType.Fields
+
 	//parse transformed field names
 	for _, transformation := range fullAvroSchema.Transformations {
 		parsedSchema.TransformedFields = append(parsedSchema.TransformedFields, transformation.OutputField)
 	}
 
 	//parse database columns
-	parsedSchema.DatabaseColumns = s.parseDatabaseColumns(fullAvroSchema, parsedSchema.TransformedFields)
+	parsedSchema.DatabaseColumns = s.parseDatabaseColumns(parsedSchema.RootNode, parsedSchema.Fields, parsedSchema.TransformedFields)
 
 	return
 }
 
-func (s *SchemaParser) parseDatabaseColumns(fullAvroSchema avro.Schema, transformedFields []string) (dbColumns []string) {
-	root := fullAvroSchema.Fields[0].Name
-
-	for _, field := range fullAvroSchema.Fields[0].Type[0].Fields {
+// parseDatabaseColumns returns the top level fields of the root node. Nested records are read from a single column.
+func (s *SchemaParser) parseDatabaseColumns(root string, fields []Field, transformedFields []string) (dbColumns []string) {
+	for _, field := range fields {
 		if !slices.Contains(transformedFields, root+"."+field.Name) && !slices.Contains(common.InternalFields, field.Name) {
 			dbColumns = append(dbColumns, field.Name)
 		}
@@ -57,3 +86,13 @@ func (s *SchemaParser) parseDatabaseColumns(fullAvroSchema avro.Schema, transfor
 
 	return
 }
+
+// PrimaryKeyIsUUID returns whether the primary key field of the root node is a UUID
+func (p ParsedAvroSchema) PrimaryKeyIsUUID() bool {
+	for _, field := range p.Fields {
+		if field.Type.PrimaryKey {
+			return field.Type.UUID
+		}
+	}
+	return false
+}
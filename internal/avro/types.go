@@ -0,0 +1,212 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-errors/errors"
+)
+
+// Type is a resolved avro type. A nullable union is resolved to its type that is not null with Nullable set,
+// in either order. Records, arrays and maps are resolved recursively.
+type Type struct {
+	Type       string //avro type, e.g. record, array, map, string, long
+	Name       string
+	XJoinType  string
+	Nullable   bool
+	PrimaryKey bool
+	UUID       bool    //a string with the uuid logicalType or the Debezium Uuid connect.name
+	Fields     []Field //record
+	Items      *Type   //array
+	Values     *Type   //map
+	Branches   []Type  //union of more than one type that is not null
+}
+
+type Field struct {
+	Name string
+	Type Type
+}
+
+var primitiveTypes = []string{"null", "boolean", "int", "long", "float", "double", "bytes", "string"}
+
+// typeResolver resolves the types of a schema. Named types can be referenced by their short or full name after they
+// are defined.
+type typeResolver struct {
+	named     map[string]Type //keyed by the short and the full name
+	namespace string          //namespace of the enclosing named type, inherited by the names without a namespace
+}
+
+// resolveSchema resolves the type of a full avro schema
+func resolveSchema(schema string) (Type, error) {
+	var raw interface{}
+	err := json.Unmarshal([]byte(schema), &raw)
+	if err != nil {
+		return Type{}, errors.Wrap(err, 0)
+	}
+
+	resolver := typeResolver{named: make(map[string]Type)}
+	return resolver.resolve(raw)
+}
+
+func (t *typeResolver) resolve(raw interface{}) (Type, error) {
+	switch typedValue := raw.(type) {
+	case string:
+		return t.resolveName(typedValue)
+	case []interface{}:
+		return t.resolveUnion(typedValue)
+	case map[string]interface{}:
+		return t.resolveObject(typedValue)
+	default:
+		return Type{}, errors.Wrap(fmt.Errorf("invalid avro type: %v", raw), 0)
+	}
+}
+
+func (t *typeResolver) resolveName(name string) (Type, error) {
+	for _, primitive := range primitiveTypes {
+		if name == primitive {
+			return Type{Type: name}, nil
+		}
+	}
+	//a short name refers to the enclosing namespace first
+	if t.namespace != "" && !strings.Contains(name, ".") {
+		if named, ok := t.named[t.namespace+"."+name]; ok {
+			return named, nil
+		}
+	}
+	if named, ok := t.named[name]; ok {
+		return named, nil
+	}
+	return Type{}, errors.Wrap(errors.New("unknown avro type: "+name), 0)
+}
+
+// namespaceOf returns the namespace of a named type, from its full name, its namespace attribute or the enclosing
+// namespace
+func (t *typeResolver) namespaceOf(object map[string]interface{}) string {
+	name, _ := object["name"].(string)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	if namespace, ok := object["namespace"].(string); ok {
+		return namespace
+	}
+	return t.namespace
+}
+
+// register adds a named type by its short and its full name
+func (t *typeResolver) register(object map[string]interface{}, resolved Type) {
+	name, _ := object["name"].(string)
+	shortName := name[strings.LastIndex(name, ".")+1:]
+	t.named[shortName] = resolved
+	if namespace := t.namespaceOf(object); namespace != "" {
+		t.named[namespace+"."+shortName] = resolved
+	}
+}
+
+func (t *typeResolver) resolveUnion(branches []interface{}) (resolved Type, err error) {
+	var nullable bool
+	var types []Type
+	for _, branch := range branches {
+		branchType, err := t.resolve(branch)
+		if err != nil {
+			return resolved, err
+		}
+		if branchType.Type == "null" {
+			nullable = true
+		} else {
+			types = append(types, branchType)
+		}
+	}
+
+	switch len(types) {
+	case 0:
+		resolved = Type{Type: "null"}
+	case 1:
+		resolved = types[0]
+	default:
+		resolved = Type{Type: "union", Branches: types}
+	}
+	resolved.Nullable = resolved.Nullable || nullable
+	return resolved, nil
+}
+
+func (t *typeResolver) resolveObject(object map[string]interface{}) (resolved Type, err error) {
+	//the type of an object is either a name or a nested type, e.g. {"type": {"type": "record", ...}}
+	switch typeValue := object["type"].(type) {
+	case string:
+		switch typeValue {
+		case "record", "error":
+			resolved, err = t.resolveRecord(object)
+		case "array":
+			resolved = Type{Type: "array"}
+			var items Type
+			items, err = t.resolve(object["items"])
+			resolved.Items = &items
+		case "map":
+			resolved = Type{Type: "map"}
+			var values Type
+			values, err = t.resolve(object["values"])
+			resolved.Values = &values
+		case "enum", "fixed":
+			resolved = Type{Type: typeValue}
+		default:
+			resolved, err = t.resolveName(typeValue)
+		}
+	case nil:
+		return resolved, errors.Wrap(errors.New("avro type is missing the type attribute"), 0)
+	default:
+		resolved, err = t.resolve(typeValue)
+	}
+	if err != nil {
+		return resolved, err
+	}
+
+	if name, ok := object["name"].(string); ok {
+		resolved.Name = name
+		if resolved.Type == "enum" || resolved.Type == "fixed" {
+			t.register(object, resolved)
+		}
+	}
+	if xjoinType, ok := object["xjoin.type"].(string); ok {
+		resolved.XJoinType = xjoinType
+	}
+	if primaryKey, ok := object["xjoin.primary.key"].(bool); ok {
+		resolved.PrimaryKey = primaryKey
+	}
+	if object["logicalType"] == "uuid" || object["connect.name"] == "io.debezium.data.Uuid" {
+		resolved.UUID = true
+	}
+
+	return resolved, nil
+}
+
+func (t *typeResolver) resolveRecord(object map[string]interface{}) (resolved Type, err error) {
+	resolved = Type{Type: "record"}
+
+	//the types nested in the record inherit its namespace
+	enclosingNamespace := t.namespace
+	t.namespace = t.namespaceOf(object)
+	defer func() { t.namespace = enclosingNamespace }()
+
+	rawFields, _ := object["fields"].([]interface{})
+	for _, rawField := range rawFields {
+		fieldObject, ok := rawField.(map[string]interface{})
+		if !ok {
+			return resolved, errors.Wrap(fmt.Errorf("invalid avro field: %v", rawField), 0)
+		}
+		name, _ := fieldObject["name"].(string)
+
+		fieldType, err := t.resolve(fieldObject["type"])
+		if err != nil {
+			return resolved, errors.Wrap(fmt.Errorf("invalid type of field %s: %w", name, err), 0)
+		}
+		resolved.Fields = append(resolved.Fields, Field{Name: name, Type: fieldType})
+	}
+
+	//register the record so later fields can reference it by name
+	if name, ok := object["name"].(string); ok {
+		resolved.Name = name
+		t.register(object, resolved)
+	}
+	return resolved, nil
+}
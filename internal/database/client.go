@@ -1,18 +1,24 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
 	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
 	"github.com/go-errors/errors"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"strings"
+	"time"
 )
 
 type DBClient struct {
-	connection *sqlx.DB
-	Config     DBParams
-	log        logger.Log
+	connection     *sqlx.DB
+	replica        *sqlx.DB //used for the content queries when a replica host is configured
+	snapshot       *sqlx.Tx
+	snapshotCancel context.CancelFunc //cancels the context the snapshot transaction is bound to in EndSnapshot
+	Config         DBParams
+	log            logger.Log
 }
 
 type DBParams struct {
@@ -24,6 +30,16 @@ type DBParams struct {
 	SSLMode          string
 	SSLRootCert      string
 	Table            string
+	Dialect          Dialect //defaults to Postgres
+	ReplicaHost      string  //optional read replica for the content queries, so they do not load the primary
+	ReplicaPort      string
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration //sent as the statement_timeout run-time parameter of each connection
+	ApplicationName  string
+	ContentHashSQL   string //SQL expression computing the content hash of a row, e.g. md5(row_to_json(t)::text)
+	Filter           filter.Filter
 	ParsedAvroSchema avro.ParsedAvroSchema
 	Log              logger.Log
 }
@@ -48,31 +64,38 @@ func NewTestDBClient(connection *sqlx.DB, config DBParams) *DBClient {
 	return &dbClient
 }
 
+// NewTestReplicaDBClient is a test client whose content queries run on replica
+func NewTestReplicaDBClient(connection *sqlx.DB, replica *sqlx.DB, config DBParams) *DBClient {
+	dbClient := NewTestDBClient(connection, config)
+	dbClient.replica = replica
+	return dbClient
+}
+
 func (d *DBClient) GetConnection() (connection *sqlx.DB, err error) {
-	connectionStringTemplate := "host=%s user=%s password=%s port=%s sslmode=%s"
+	return d.connect(d.Config.Host, d.Config.Port)
+}
 
-	if d.Config.SSLMode != "disable" {
-		connectionStringTemplate = connectionStringTemplate + " sslrootcert=" + d.Config.SSLRootCert
+func (d *DBClient) connect(host string, port string) (connection *sqlx.DB, err error) {
+	connStr, err := d.dialect().ConnectionString(d.Config, host, port)
+	if err != nil {
+		return nil, err
 	}
 
-	connStr := fmt.Sprintf(
-		connectionStringTemplate,
-		d.Config.Host,
-		d.Config.User,
-		d.Config.Password,
-		d.Config.Port,
-		d.Config.SSLMode)
-
-	//d.Config.Name is empty before creating the test database
-	if d.Config.Name != "" {
-		connStr = connStr + " dbname=" + d.Config.Name
+	if connection, err = sqlx.Connect(d.dialect().DriverName(), connStr); err != nil {
+		return nil, err
 	}
 
-	if connection, err = sqlx.Connect("postgres", connStr); err != nil {
-		return nil, err
-	} else {
-		return connection, nil
+	if d.Config.MaxOpenConns > 0 {
+		connection.SetMaxOpenConns(d.Config.MaxOpenConns)
+	}
+	if d.Config.MaxIdleConns > 0 {
+		connection.SetMaxIdleConns(d.Config.MaxIdleConns)
 	}
+	if d.Config.ConnMaxLifetime > 0 {
+		connection.SetConnMaxLifetime(d.Config.ConnMaxLifetime)
+	}
+
+	return connection, nil
 }
 
 func (d *DBClient) Connect() (err error) {
@@ -84,6 +107,16 @@ func (d *DBClient) Connect() (err error) {
 		return fmt.Errorf("error connecting to %s:%s/%s as %s : %s", d.Config.Host, d.Config.Port, d.Config.Name, d.Config.User, err)
 	}
 
+	if d.Config.ReplicaHost != "" {
+		replicaPort := d.Config.ReplicaPort
+		if replicaPort == "" {
+			replicaPort = d.Config.Port
+		}
+		if d.replica, err = d.connect(d.Config.ReplicaHost, replicaPort); err != nil {
+			return fmt.Errorf("error connecting to replica %s:%s/%s as %s : %s", d.Config.ReplicaHost, replicaPort, d.Config.Name, d.Config.User, err)
+		}
+	}
+
 	return nil
 }
 
@@ -91,7 +124,7 @@ func (d *DBClient) runQuery(query string) (*sqlx.Rows, error) {
 	if d.connection == nil {
 		return nil, errors.Wrap(errors.New("cannot run query because there is no database connection"), 0)
 	}
-	rows, err := d.connection.Queryx(query)
+	rows, err := d.queryer().Queryx(query)
 
 	if err != nil {
 		return nil, errors.Wrap(fmt.Errorf("error executing query (%s) : %w", query, err), 0)
@@ -100,6 +133,46 @@ func (d *DBClient) runQuery(query string) (*sqlx.Rows, error) {
 	return rows, nil
 }
 
+// runContentQuery runs the heavy content queries on the replica when there is one. The snapshot of the consistency
+// mode takes precedence because the rows have to be read at the same point in time as the ids.
+func (d *DBClient) runContentQuery(query string) (*sqlx.Rows, error) {
+	if d.replica == nil || d.snapshot != nil {
+		return d.runQuery(query)
+	}
+
+	rows, err := d.replica.Queryx(query)
+	if err != nil {
+		return nil, errors.Wrap(fmt.Errorf("error executing query on replica (%s) : %w", query, err), 0)
+	}
+
+	return rows, nil
+}
+
+func (d *DBClient) dialect() Dialect {
+	if d.Config.Dialect == nil {
+		return PostgresDialect{}
+	}
+	return d.Config.Dialect
+}
+
+// where returns a WHERE clause that combines the conditions with the row filter, or an empty string
+func (d *DBClient) where(conditions ...string) string {
+	var clauses []string
+	for _, condition := range conditions {
+		if condition != "" {
+			clauses = append(clauses, condition)
+		}
+	}
+	if filterSQL := d.Config.Filter.SQL(d.dialect()); filterSQL != "" {
+		clauses = append(clauses, "("+filterSQL+")")
+	}
+
+	if len(clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(clauses, " AND ")
+}
+
 func (d *DBClient) closeRows(rows *sqlx.Rows) {
 	if rows != nil {
 		err := rows.Close()
@@ -8,24 +8,26 @@ import (
 )
 
 func (d *DBClient) GetRowsByIDs(ids []string) (records []map[string]interface{}, err error) {
-	cols := strings.Join(d.Config.ParsedAvroSchema.DatabaseColumns, ",")
-
-	idsString, err := formatIdsList(ids)
-	if err != nil {
-		return records, errors.Wrap(err, 0)
+	var columns []string
+	for _, col := range d.Config.ParsedAvroSchema.DatabaseColumns {
+		columns = append(columns, d.dialect().QuoteIdentifier(col))
 	}
+	cols := strings.Join(columns, ",")
+
+	idsString := d.formatIdsList(ids)
 
 	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE ID IN (%s) ORDER BY id",
-		cols, d.Config.Table, idsString)
+		"SELECT %s FROM %s%s ORDER BY id",
+		cols, d.Config.Table, d.where("ID IN ("+idsString+")"))
 
-	rows, err := d.connection.Queryx(query)
+	rows, err := d.runContentQuery(query)
 	defer d.closeRows(rows)
 
 	if err != nil {
-		return records, errors.Wrap(fmt.Errorf("error executing query %s, %w", query, err), 0)
+		return records, errors.Wrap(err, 0)
 	}
 
+	var parseErrors ParseErrors
 	for rows.Next() {
 		record := map[string]interface{}{}
 		for _, col := range d.Config.ParsedAvroSchema.DatabaseColumns {
@@ -45,12 +47,19 @@ func (d *DBClient) GetRowsByIDs(ids []string) (records []map[string]interface{},
 			ParsedAvroSchema: d.Config.ParsedAvroSchema,
 		}
 		record, err = recordParser.Parse()
-		if err != nil {
+		if fieldError, ok := AsFieldParseError(err); ok {
+			parseErrors = append(parseErrors, fieldError)
+			continue
+		} else if err != nil {
 			return records, errors.Wrap(err, 0)
 		}
 
 		records = append(records, record)
 	}
 
-	return
+	if len(parseErrors) > 0 {
+		return records, errors.Wrap(parseErrors, 0)
+	}
+
+	return records, nil
 }
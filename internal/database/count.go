@@ -6,7 +6,7 @@ import (
 )
 
 func (d *DBClient) CountTable() (count int, err error) {
-	rows, err := d.runQuery(fmt.Sprintf("SELECT count(*) from %s", d.Config.Table))
+	rows, err := d.runQuery(fmt.Sprintf("SELECT count(*) from %s%s", d.Config.Table, d.where()))
 	defer d.closeRows(rows)
 
 	if err != nil {
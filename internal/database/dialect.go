@@ -0,0 +1,274 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
+	"github.com/go-errors/errors"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+)
+
+// plainIdentifier matches the identifiers that do not need to be quoted in either database
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Dialect is the SQL syntax and connection handling that differs between the source databases
+type Dialect interface {
+	filter.SQLDialect
+
+	// DriverName is the database/sql driver used to connect
+	DriverName() string
+
+	// ConnectionString returns the data source name of the database at host:port
+	ConnectionString(config DBParams, host string, port string) (string, error)
+
+	// QuoteIdentifier quotes a column name when it is not a plain lower case identifier
+	QuoteIdentifier(name string) string
+
+	// TimestampLiteral returns t as a literal that can be compared with a timestamp column
+	TimestampLiteral(t time.Time) string
+
+	// Text casts expression to a string
+	Text(expression string) string
+
+	// DateTrunc truncates the timestamp column to the UTC start of its minute, hour or day
+	DateTrunc(interval string, column string) string
+
+	// Sample returns the table suffix and the condition that select about percentage of the rows at random
+	Sample(percentage float64) (tableSuffix string, condition string)
+
+	// HashSum returns the sum of the first 64 bits of the hex hashes computed by expression, as a decimal string
+	HashSum(expression string) string
+
+	// EstimateCountQuery returns a query of the estimated row count of table that does not scan the table
+	EstimateCountQuery(table string, method string) (string, error)
+}
+
+// DialectByName returns the dialect of the database type name. An empty name is Postgres.
+func DialectByName(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", DialectPostgres, "postgresql":
+		return PostgresDialect{}, nil
+	case DialectMySQL:
+		return MySQLDialect{}, nil
+	default:
+		return nil, errors.Wrap(errors.New("invalid database dialect: "+name), 0)
+	}
+}
+
+// PostgresDialect connects with lib/pq. The session time zone is UTC so timestamp columns without a time zone are
+// read and compared as UTC, like timestamptz columns.
+type PostgresDialect struct{}
+
+func (PostgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (PostgresDialect) ConnectionString(config DBParams, host string, port string) (string, error) {
+	connectionStringTemplate := "host=%s user=%s password=%s port=%s sslmode=%s timezone=UTC"
+
+	if config.SSLMode != "disable" {
+		connectionStringTemplate = connectionStringTemplate + " sslrootcert=" + config.SSLRootCert
+	}
+
+	connStr := fmt.Sprintf(
+		connectionStringTemplate,
+		host,
+		config.User,
+		config.Password,
+		port,
+		config.SSLMode)
+
+	if config.ApplicationName != "" {
+		connStr = connStr + " application_name=" + connectionValue(config.ApplicationName)
+	}
+	if config.StatementTimeout > 0 {
+		connStr = connStr + fmt.Sprintf(" statement_timeout=%d", config.StatementTimeout.Milliseconds())
+	}
+
+	//config.Name is empty before creating the test database
+	if config.Name != "" {
+		connStr = connStr + " dbname=" + config.Name
+	}
+
+	return connStr, nil
+}
+
+// connectionValue quotes a value of a libpq connection string so it can contain spaces, quotes and backslashes
+func connectionValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+func (PostgresDialect) QuoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (PostgresDialect) QuoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func (PostgresDialect) NotEqual(left string, right string) string {
+	return fmt.Sprintf("%s IS DISTINCT FROM %s", left, right)
+}
+
+func (PostgresDialect) TimestampLiteral(t time.Time) string {
+	//a timestamp column without a time zone ignores the offset of the literal
+	return "'" + t.UTC().Format(time.RFC3339Nano) + "'"
+}
+
+func (PostgresDialect) Text(expression string) string {
+	return expression + "::text"
+}
+
+// DateTrunc casts the column to timestamptz first: AT TIME ZONE converts a timestamp without a time zone the other
+// way, from the UTC wall time to a timestamptz, and the cast reads it in the UTC session time zone instead
+func (PostgresDialect) DateTrunc(interval string, column string) string {
+	return fmt.Sprintf("date_trunc('%s', %s::timestamptz AT TIME ZONE 'UTC')", interval, column)
+}
+
+func (PostgresDialect) Sample(percentage float64) (string, string) {
+	return fmt.Sprintf(" TABLESAMPLE BERNOULLI (%f)", percentage), ""
+}
+
+func (PostgresDialect) HashSum(expression string) string {
+	//the sum of the signed values is the same modulo 2^64 as the sum of the unsigned values
+	return fmt.Sprintf("sum(('x' || substr(%s, 1, 16))::bit(64)::bigint)::text", expression)
+}
+
+func (PostgresDialect) EstimateCountQuery(table string, method string) (string, error) {
+	switch method {
+	case "reltuples":
+		return fmt.Sprintf(`SELECT reltuples::bigint FROM pg_class WHERE oid = '%s'::regclass`, table), nil
+	case "n_live_tup":
+		return fmt.Sprintf(
+			`SELECT CASE WHEN last_analyze IS NULL AND last_autoanalyze IS NULL THEN -1 ELSE n_live_tup END `+
+				`FROM pg_stat_user_tables WHERE relid = '%s'::regclass`, table), nil
+	default:
+		return "", errors.Wrap(errors.New("invalid count estimate method: "+method), 0)
+	}
+}
+
+// MySQLDialect connects with go-sql-driver/mysql. The session time zone is UTC so timestamps are read and
+// compared in UTC.
+type MySQLDialect struct{}
+
+func (MySQLDialect) DriverName() string {
+	return "mysql"
+}
+
+func (MySQLDialect) ConnectionString(config DBParams, host string, port string) (string, error) {
+	mysqlConfig := mysql.NewConfig()
+	mysqlConfig.User = config.User
+	mysqlConfig.Passwd = config.Password
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = net.JoinHostPort(host, port)
+	mysqlConfig.DBName = config.Name
+	mysqlConfig.ParseTime = true
+	mysqlConfig.Loc = time.UTC
+	mysqlConfig.Params = map[string]string{"time_zone": "'+00:00'"}
+
+	if config.StatementTimeout > 0 {
+		//only limits SELECT statements
+		mysqlConfig.Params["max_execution_time"] = fmt.Sprintf("%d", config.StatementTimeout.Milliseconds())
+	}
+
+	switch config.SSLMode {
+	case "", "disable":
+	case "require":
+		mysqlConfig.TLSConfig = "skip-verify"
+	default:
+		mysqlConfig.TLSConfig = "true"
+		if config.SSLRootCert != "" {
+			rootCert, err := os.ReadFile(config.SSLRootCert)
+			if err != nil {
+				return "", errors.Wrap(err, 0)
+			}
+			rootCertPool := x509.NewCertPool()
+			if !rootCertPool.AppendCertsFromPEM(rootCert) {
+				return "", errors.Wrap(errors.New("invalid ssl root certificate: "+config.SSLRootCert), 0)
+			}
+
+			err = mysql.RegisterTLSConfig("xjoin-validation", &tls.Config{RootCAs: rootCertPool, ServerName: host})
+			if err != nil {
+				return "", errors.Wrap(err, 0)
+			}
+			mysqlConfig.TLSConfig = "xjoin-validation"
+		}
+	}
+
+	return mysqlConfig.FormatDSN(), nil
+}
+
+func (MySQLDialect) QuoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (MySQLDialect) QuoteString(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func (MySQLDialect) NotEqual(left string, right string) string {
+	return fmt.Sprintf("NOT (%s <=> %s)", left, right)
+}
+
+func (MySQLDialect) TimestampLiteral(t time.Time) string {
+	return "'" + t.UTC().Format("2006-01-02 15:04:05.999999") + "'"
+}
+
+func (MySQLDialect) Text(expression string) string {
+	return fmt.Sprintf("CAST(%s AS CHAR)", expression)
+}
+
+func (MySQLDialect) DateTrunc(interval string, column string) string {
+	format := "%Y-%m-%d %H:%i:00"
+	switch interval {
+	case "hour":
+		format = "%Y-%m-%d %H:00:00"
+	case "day":
+		format = "%Y-%m-%d 00:00:00"
+	}
+	return fmt.Sprintf("CAST(DATE_FORMAT(%s, '%s') AS DATETIME)", column, format)
+}
+
+func (MySQLDialect) Sample(percentage float64) (string, string) {
+	return "", fmt.Sprintf("RAND() < %f", percentage/100)
+}
+
+func (MySQLDialect) HashSum(expression string) string {
+	return fmt.Sprintf("CAST(SUM(CAST(CONV(SUBSTR(%s, 1, 16), 16, 10) AS UNSIGNED)) AS CHAR)", expression)
+}
+
+// EstimateCountQuery reads TABLE_ROWS of information_schema.tables, the InnoDB estimate, for the reltuples method.
+// MySQL has no equivalent of n_live_tup.
+func (d MySQLDialect) EstimateCountQuery(table string, method string) (string, error) {
+	switch method {
+	case "reltuples":
+		return fmt.Sprintf(
+			`SELECT COALESCE(TABLE_ROWS, -1) FROM information_schema.tables `+
+				`WHERE table_schema = DATABASE() AND table_name = %s`, d.QuoteString(table)), nil
+	case "n_live_tup":
+		return "", errors.Wrap(errors.New("the n_live_tup count estimate method is not supported by MySQL"), 0)
+	default:
+		return "", errors.Wrap(errors.New("invalid count estimate method: "+method), 0)
+	}
+}
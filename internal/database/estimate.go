@@ -0,0 +1,37 @@
+package database
+
+import (
+	"github.com/go-errors/errors"
+)
+
+// EstimateTableCount returns the estimated number of rows from the planner statistics (reltuples) or the
+// statistics collector (n_live_tup), without scanning the table. The count is -1, or 0 for reltuples before
+// Postgres 14, when the table has not been analyzed yet. On MySQL reltuples reads the table statistics of information_schema.
+func (d *DBClient) EstimateTableCount(method string) (count int, err error) {
+	if len(d.Config.Filter) > 0 {
+		return count, errors.Wrap(errors.New("count estimates cannot be used with a row filter"), 0)
+	}
+
+	query, err := d.dialect().EstimateCountQuery(d.Config.Table, method)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	d.log.Debug("Database EstimateTableCount query", "query", query)
+
+	rows, err := d.runQuery(query)
+	defer d.closeRows(rows)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	count = -1
+	for rows.Next() {
+		err = rows.Scan(&count)
+		if err != nil {
+			return count, errors.Wrap(err, 0)
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/go-errors/errors"
+)
+
+// GetRowHashesByIDs returns the content hash of each row by id computed by the ContentHashSQL expression.
+// Requires ContentHashSQL.
+func (d *DBClient) GetRowHashesByIDs(ids []string) (hashes map[string]string, err error) {
+	hashes = make(map[string]string)
+	if d.Config.ContentHashSQL == "" {
+		return hashes, errors.Wrap(errors.New("hash comparison requires a content hash SQL expression"), 0)
+	}
+
+	idsString := d.formatIdsList(ids)
+
+	//the table is aliased as t so the expression can reference the whole row
+	query := fmt.Sprintf(
+		"SELECT id, %s AS hash FROM %s AS t%s",
+		d.Config.ContentHashSQL, d.Config.Table, d.where("id IN ("+idsString+")"))
+
+	d.log.Debug("Database GetRowHashesByIDs query", "query", query)
+
+	return d.queryHashes(query)
+}
+
+func (d *DBClient) queryHashes(query string) (hashes map[string]string, err error) {
+	hashes = make(map[string]string)
+
+	rows, err := d.runContentQuery(query)
+	defer d.closeRows(rows)
+	if err != nil {
+		return hashes, errors.Wrap(err, 0)
+	}
+
+	for rows.Next() {
+		var id, hash string
+		err = rows.Scan(&id, &hash)
+		if err != nil {
+			return hashes, errors.Wrap(err, 0)
+		}
+		hashes[id] = hash
+	}
+
+	return hashes, nil
+}
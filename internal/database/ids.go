@@ -1,17 +1,17 @@
 package database
 
 import (
-	"bytes"
 	"fmt"
-	"text/template"
+	"strings"
 	"time"
 )
 
 func (d *DBClient) GetIDsByModifiedOn(start time.Time, end time.Time) (ids []string, err error) {
 	//TODO: parse name of id and modified_on fields from avro schema
 	query := fmt.Sprintf(
-		`SELECT id FROM %s WHERE modified_on > '%s' AND modified_on < '%s' ORDER BY id `,
-		d.Config.Table, start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano))
+		`SELECT id FROM %s%s ORDER BY id `,
+		d.Config.Table, d.where(fmt.Sprintf(
+			"modified_on > %s AND modified_on < %s", d.dialect().TimestampLiteral(start), d.dialect().TimestampLiteral(end))))
 
 	d.log.Debug("Database GetIDsByModifiedOn query", "query", query)
 
@@ -19,12 +19,37 @@ func (d *DBClient) GetIDsByModifiedOn(start time.Time, end time.Time) (ids []str
 }
 
 func (d *DBClient) GetIDsByIDList(ids []string) (responseIds []string, err error) {
-	idsString, err := formatIdsList(ids)
-	if err != nil {
-		return nil, err
+	idsString := d.formatIdsList(ids)
+
+	query := fmt.Sprintf(`SELECT id FROM %s%s`, d.Config.Table, d.where("id in ("+idsString+")"))
+	return d.queryIds(query)
+}
+
+// GetIDsAfter returns the next limit ids in id order of the rows not modified after modifiedBefore. An empty afterID
+// starts at the first id. The ids are paged against the byte order of the index keywords, so the id column must sort
+// the same way, e.g. a uuid column or a text column with the C collation.
+func (d *DBClient) GetIDsAfter(afterID string, limit int, modifiedBefore time.Time) (ids []string, err error) {
+	//TODO: parse name of id and modified_on fields from avro schema
+	after := ""
+	if afterID != "" {
+		after = "id > " + d.dialect().QuoteString(afterID)
 	}
+	query := fmt.Sprintf(`SELECT id FROM %s%s ORDER BY id LIMIT %d`,
+		d.Config.Table, d.where(after, d.modifiedBeforeCondition(modifiedBefore)), limit)
+
+	d.log.Debug("Database GetIDsAfter query", "query", query)
+
+	return d.queryIds(query)
+}
+
+// SampleIDs returns a random sample of about percentage of the rows, using TABLESAMPLE BERNOULLI on Postgres
+func (d *DBClient) SampleIDs(percentage float64) (ids []string, err error) {
+	//TODO: parse name of id field from avro schema
+	tableSuffix, condition := d.dialect().Sample(percentage)
+	query := fmt.Sprintf(`SELECT id FROM %s%s%s ORDER BY id`, d.Config.Table, tableSuffix, d.where(condition))
+
+	d.log.Debug("Database SampleIDs query", "query", query)
 
-	query := fmt.Sprintf(`SELECT id FROM %s WHERE id in (%s)`, d.Config.Table, idsString)
 	return d.queryIds(query)
 }
 
@@ -52,21 +77,17 @@ func (d *DBClient) queryIds(query string) ([]string, error) {
 	return ids, nil
 }
 
-func formatIdsList(ids []string) (string, error) {
-	idsMap := make(map[string]interface{})
-	idsMap["IDs"] = ids
-
-	tmpl, err := template.New("ids").Parse(`{{range $idx, $id := .IDs}}'{{$id}}',{{end}}`)
-	if err != nil {
-		return "", err
-	}
+// modifiedBeforeCondition matches the rows not modified after modifiedBefore
+func (d *DBClient) modifiedBeforeCondition(modifiedBefore time.Time) string {
+	//TODO: parse name of modified_on field from avro schema
+	return "modified_on <= " + d.dialect().TimestampLiteral(modifiedBefore)
+}
 
-	var idsTemplateBuffer bytes.Buffer
-	err = tmpl.Execute(&idsTemplateBuffer, idsMap)
-	if err != nil {
-		return "", err
+// formatIdsList returns the ids as a comma separated list of string literals
+func (d *DBClient) formatIdsList(ids []string) string {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = d.dialect().QuoteString(id)
 	}
-	idsTemplateParsed := idsTemplateBuffer.String()
-	idsTemplateParsed = idsTemplateParsed[0 : len(idsTemplateParsed)-1]
-	return idsTemplateParsed, nil
+	return strings.Join(quoted, ",")
 }
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/go-errors/errors"
+)
+
+// GetRowBucketHashes returns the number of rows not modified after modifiedBefore and the sum of their hashes for
+// each bucket of UUIDs that start with prefix, keyed by the child prefix of the bucket. Requires ContentHashSQL.
+func (d *DBClient) GetRowBucketHashes(prefix string, modifiedBefore time.Time) (buckets map[string]record.BucketHash, err error) {
+	buckets = make(map[string]record.BucketHash)
+	if d.Config.ContentHashSQL == "" {
+		return buckets, errors.Wrap(errors.New("range comparison requires a content hash SQL expression"), 0)
+	}
+	children := record.UUIDChildPrefixes(prefix)
+	if len(children) == 0 {
+		return buckets, errors.Wrap(errors.New("a whole id cannot be split into buckets: "+prefix), 0)
+	}
+
+	//TODO: parse name of id field from avro schema
+	query := fmt.Sprintf(
+		`SELECT substr(%s, 1, %d) AS bucket, count(*), %s FROM %s AS t%s GROUP BY bucket`,
+		d.dialect().Text("id"), len(children[0]), d.dialect().HashSum(d.Config.ContentHashSQL), d.Config.Table,
+		d.where(d.idPrefixCondition(prefix), d.modifiedBeforeCondition(modifiedBefore)))
+
+	d.log.Debug("Database GetRowBucketHashes query", "query", query)
+
+	rows, err := d.runContentQuery(query)
+	defer d.closeRows(rows)
+	if err != nil {
+		return buckets, errors.Wrap(err, 0)
+	}
+
+	modulus := new(big.Int).Lsh(big.NewInt(1), 64)
+	for rows.Next() {
+		var bucket, sum string
+		var count int
+		err = rows.Scan(&bucket, &count, &sum)
+		if err != nil {
+			return buckets, errors.Wrap(err, 0)
+		}
+
+		hash, ok := new(big.Int).SetString(sum, 10)
+		if !ok {
+			return buckets, errors.Wrap(errors.New("invalid bucket hash sum: "+sum), 0)
+		}
+		buckets[bucket] = record.BucketHash{
+			Count: count,
+			Hash:  hash.Mod(hash, modulus).Uint64(),
+		}
+	}
+
+	return buckets, nil
+}
+
+// GetRowHashesByIDPrefix returns the hash of each row not modified after modifiedBefore whose id starts with prefix.
+// Requires ContentHashSQL.
+func (d *DBClient) GetRowHashesByIDPrefix(prefix string, _ int, modifiedBefore time.Time) (hashes map[string]string, err error) {
+	hashes = make(map[string]string)
+	if d.Config.ContentHashSQL == "" {
+		return hashes, errors.Wrap(errors.New("range comparison requires a content hash SQL expression"), 0)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, %s AS hash FROM %s AS t%s`,
+		d.Config.ContentHashSQL, d.Config.Table, d.where(d.idPrefixCondition(prefix), d.modifiedBeforeCondition(modifiedBefore)))
+
+	d.log.Debug("Database GetRowHashesByIDPrefix query", "query", query)
+
+	return d.queryHashes(query)
+}
+
+// idPrefixCondition compares the native id column with the UUIDs that bound the prefix, so the index of the column
+// can be used
+func (d *DBClient) idPrefixCondition(prefix string) string {
+	lower, upper := record.UUIDPrefixRange(prefix)
+	var conditions []string
+	if lower != "" {
+		conditions = append(conditions, "id >= "+d.dialect().QuoteString(lower))
+	}
+	if upper != "" {
+		conditions = append(conditions, "id < "+d.dialect().QuoteString(upper))
+	}
+	return strings.Join(conditions, " AND ")
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/go-errors/errors"
+)
+
+// DefaultTouchSQL updates modified_on so the change data capture connector re-emits the rows
+const DefaultTouchSQL = `UPDATE {{.Table}} SET modified_on = now() WHERE id IN ({{.IDs}})`
+
+// TouchRows runs the touchSQL template for the ids. The template has access to .Table and .IDs,
+// the comma separated list of quoted ids.
+func (d *DBClient) TouchRows(ids []string, touchSQL string) (err error) {
+	if d.connection == nil {
+		return errors.Wrap(errors.New("cannot touch rows because there is no database connection"), 0)
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if touchSQL == "" {
+		touchSQL = DefaultTouchSQL
+	}
+
+	idsString := d.formatIdsList(ids)
+
+	tmpl, err := template.New("touch").Parse(touchSQL)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	var query bytes.Buffer
+	err = tmpl.Execute(&query, map[string]string{
+		"Table": d.Config.Table,
+		"IDs":   idsString,
+	})
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	_, err = d.connection.Exec(query.String())
+	if err != nil {
+		return errors.Wrap(fmt.Errorf("error executing query (%s) : %w", query.String(), err), 0)
+	}
+
+	return
+}
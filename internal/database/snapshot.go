@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+// BeginSnapshot starts a read only REPEATABLE READ transaction. Every query runs inside the transaction until
+// EndSnapshot is called, so each query reads the same snapshot of the table.
+// The transaction is not safe for concurrent queries. Its context lives until EndSnapshot, because database/sql
+// rolls the transaction back as soon as the context of BeginTxx is cancelled.
+func (d *DBClient) BeginSnapshot() (snapshotTime time.Time, err error) {
+	if d.connection == nil {
+		return snapshotTime, errors.Wrap(errors.New("cannot begin snapshot because there is no database connection"), 0)
+	}
+	if d.snapshot != nil {
+		return snapshotTime, errors.Wrap(errors.New("a snapshot is already in progress"), 0)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.snapshot, err = d.connection.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		cancel()
+		d.snapshot = nil
+		return snapshotTime, errors.Wrap(err, 0)
+	}
+	d.snapshotCancel = cancel
+
+	//the snapshot is taken by the first query in the transaction, now() returns the start of the transaction
+	err = d.snapshot.QueryRowx("SELECT now()").Scan(&snapshotTime)
+	if err != nil {
+		_ = d.EndSnapshot()
+		return snapshotTime, errors.Wrap(err, 0)
+	}
+
+	d.log.Debug("Started database snapshot", "snapshotTime", snapshotTime)
+
+	return snapshotTime, nil
+}
+
+func (d *DBClient) EndSnapshot() error {
+	if d.snapshot == nil {
+		return nil
+	}
+
+	err := d.snapshot.Rollback()
+	d.snapshotCancel()
+	d.snapshot = nil
+	d.snapshotCancel = nil
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
+
+// GetModifiedOnByIDs returns the modified_on value of each id
+func (d *DBClient) GetModifiedOnByIDs(ids []string) (modifiedOn map[string]time.Time, err error) {
+	modifiedOn = make(map[string]time.Time)
+	if len(ids) == 0 {
+		return
+	}
+
+	idsString := d.formatIdsList(ids)
+
+	//TODO: parse name of id and modified_on fields from avro schema
+	rows, err := d.runQuery(fmt.Sprintf(`SELECT id, modified_on FROM %s WHERE id in (%s)`, d.Config.Table, idsString))
+	defer d.closeRows(rows)
+	if err != nil {
+		return modifiedOn, errors.Wrap(err, 0)
+	}
+
+	for rows.Next() {
+		var id string
+		var modified time.Time
+		err = rows.Scan(&id, &modified)
+		if err != nil {
+			return modifiedOn, errors.Wrap(err, 0)
+		}
+		modifiedOn[id] = modified
+	}
+
+	return
+}
+
+func (d *DBClient) queryer() sqlx.Queryer {
+	if d.snapshot != nil {
+		return d.snapshot
+	}
+	return d.connection
+}
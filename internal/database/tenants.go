@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// CountTableByTenant counts the rows of each value of the tenant column. NULL is counted as an empty tenant.
+func (d *DBClient) CountTableByTenant(column string) (counts map[string]int, err error) {
+	counts = make(map[string]int)
+
+	query := fmt.Sprintf(`SELECT %s, count(*) FROM %s%s GROUP BY 1`,
+		d.dialect().Text(d.dialect().QuoteIdentifier(column)), d.Config.Table, d.where())
+
+	d.log.Debug("Database CountTableByTenant query", "query", query)
+
+	rows, err := d.runQuery(query)
+	defer d.closeRows(rows)
+	if err != nil {
+		return counts, errors.Wrap(err, 0)
+	}
+
+	for rows.Next() {
+		var tenant sql.NullString
+		var count int
+		err = rows.Scan(&tenant, &count)
+		if err != nil {
+			return counts, errors.Wrap(err, 0)
+		}
+		counts[tenant.String] += count
+	}
+
+	return counts, nil
+}
+
+// tenantsChunkSize is the number of ids in the IN list of each query
+const tenantsChunkSize = 10000
+
+// GetRowTenantsByIDs returns the value of the tenant column of each row by id
+func (d *DBClient) GetRowTenantsByIDs(column string, ids []string) (tenants map[string]string, err error) {
+	tenants = make(map[string]string)
+
+	for start := 0; start < len(ids); start += tenantsChunkSize {
+		idsString := d.formatIdsList(ids[start:utils.Min(start+tenantsChunkSize, len(ids))])
+
+		query := fmt.Sprintf(`SELECT id, %s FROM %s WHERE id IN (%s)`,
+			d.dialect().Text(d.dialect().QuoteIdentifier(column)), d.Config.Table, idsString)
+
+		err = d.scanTenants(query, tenants)
+		if err != nil {
+			return tenants, errors.Wrap(err, 0)
+		}
+	}
+
+	return tenants, nil
+}
+
+func (d *DBClient) scanTenants(query string, tenants map[string]string) error {
+	rows, err := d.runQuery(query)
+	defer d.closeRows(rows)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	for rows.Next() {
+		var id string
+		var tenant sql.NullString
+		err = rows.Scan(&id, &tenant)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		tenants[id] = tenant.String
+	}
+
+	return nil
+}
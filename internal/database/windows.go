@@ -0,0 +1,40 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+// CountTableByWindow counts the rows in each interval (minute, hour or day) of the timestamp column field between
+// start and end, keyed by the UTC start of each window
+func (d *DBClient) CountTableByWindow(field string, interval string, start time.Time, end time.Time) (counts map[time.Time]int, err error) {
+	counts = make(map[time.Time]int)
+	column := d.dialect().QuoteIdentifier(field)
+
+	query := fmt.Sprintf(
+		`SELECT %s AS window_start, count(*) FROM %s%s GROUP BY window_start`,
+		d.dialect().DateTrunc(interval, column), d.Config.Table, d.where(fmt.Sprintf(
+			"%s >= %s AND %s < %s", column, d.dialect().TimestampLiteral(start), column, d.dialect().TimestampLiteral(end))))
+
+	d.log.Debug("Database CountTableByWindow query", "query", query)
+
+	rows, err := d.runQuery(query)
+	defer d.closeRows(rows)
+	if err != nil {
+		return counts, errors.Wrap(err, 0)
+	}
+
+	for rows.Next() {
+		var windowStart time.Time
+		var count int
+		err = rows.Scan(&windowStart, &count)
+		if err != nil {
+			return counts, errors.Wrap(err, 0)
+		}
+		counts[windowStart.UTC()] = count
+	}
+
+	return counts, nil
+}
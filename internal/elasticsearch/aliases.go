@@ -0,0 +1,52 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// ResolveIndices returns the concrete indices that the configured index name resolves to.
+// An alias can point to multiple indices, e.g. the active and the refreshing index during a pipeline version switch.
+func (e *ESClient) ResolveIndices() (indices []string, err error) {
+	req := esapi.IndicesGetAliasRequest{
+		Index: []string{e.index},
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	res, err := req.Do(ctx, e.client)
+	if err != nil {
+		return indices, errors.Wrap(err, 0)
+	}
+
+	byteValue, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 300 {
+		return indices, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when resolving index aliases. StatusCode: %v, Body: %s",
+			res.StatusCode, byteValue)), 0)
+	}
+
+	return ParseAliasesResponse(byteValue)
+}
+
+// ParseAliasesResponse returns the sorted concrete index names from a get alias response body
+func ParseAliasesResponse(byteValue []byte) (indices []string, err error) {
+	var aliasesResponse map[string]interface{}
+	err = json.Unmarshal(byteValue, &aliasesResponse)
+	if err != nil {
+		return indices, errors.Wrap(err, 0)
+	}
+
+	for index := range aliasesResponse {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+
+	return indices, nil
+}
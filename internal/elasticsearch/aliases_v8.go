@@ -0,0 +1,24 @@
+package elasticsearch
+
+import (
+	"sort"
+
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+func (e *ES8Client) ResolveIndices() (indices []string, err error) {
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	res, err := e.client.Indices.GetAlias().Index(e.index).Do(ctx)
+	if err != nil {
+		return indices, errors.Wrap(err, 0)
+	}
+
+	for index := range res {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+
+	return indices, nil
+}
@@ -0,0 +1,109 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string      `json:"_id"`
+		Status int         `json:"status"`
+		Error  interface{} `json:"error"`
+	} `json:"items"`
+}
+
+// IndexDocuments writes each document to the index with the map key as the document id
+func (e *ESClient) IndexDocuments(documents map[string]map[string]interface{}) error {
+	ids := make([]string, 0, len(documents))
+	for id := range documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, id := range ids {
+		err := encoder.Encode(map[string]interface{}{"index": map[string]string{"_id": id}})
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		err = encoder.Encode(documents[id])
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+	}
+
+	return e.bulk(body.Bytes())
+}
+
+// DeleteDocuments deletes the documents from the index. Documents that are already deleted are ignored.
+func (e *ESClient) DeleteDocuments(ids []string) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, id := range ids {
+		err := encoder.Encode(map[string]interface{}{"delete": map[string]string{"_id": id}})
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+	}
+
+	return e.bulk(body.Bytes())
+}
+
+func (e *ESClient) bulk(body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	req := esapi.BulkRequest{
+		Index: e.index,
+		Body:  bytes.NewReader(body),
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	res, err := req.Do(ctx, e.client)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	byteValue, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 300 {
+		return errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code for bulk request. StatusCode: %v, Body: %s", res.StatusCode, byteValue)), 0)
+	}
+
+	var response bulkResponse
+	err = json.Unmarshal(byteValue, &response)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	if !response.Errors {
+		return nil
+	}
+
+	var failed []string
+	for _, item := range response.Items {
+		for action, result := range item {
+			if action == "delete" && result.Status == 404 {
+				continue
+			}
+			if result.Error != nil {
+				failed = append(failed, result.ID)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Wrap(fmt.Errorf("bulk request failed for ids: %v, Body: %s", failed, byteValue), 0)
+	}
+	return nil
+}
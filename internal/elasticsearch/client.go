@@ -2,46 +2,55 @@ package elasticsearch
 
 import (
 	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
 	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
 	"github.com/elastic/go-elasticsearch/v7"
 	"github.com/go-errors/errors"
+	"net/http"
 )
 
 type ESClient struct {
-	client           *elasticsearch.Client
-	index            string
-	rootNode         string
-	parsedAvroSchema avro.ParsedAvroSchema
-	log              logger.Log
+	*Queries
+	client   *elasticsearch.Client
+	index    string
+	rootNode string
+	log      logger.Log
+	pitID    string //set while a point in time is open
+	filter   filter.Filter
 }
 
 type ESParams struct {
-	Url              string
-	Username         string
-	Password         string
-	Index            string
-	RootNode         string
-	ParsedAvroSchema avro.ParsedAvroSchema
-	Log              logger.Log
+	Url                    string
+	Username               string
+	Password               string
+	UsernameFile           string //path to a mounted file containing the username, reloaded when it changes
+	PasswordFile           string //path to a mounted file containing the password, reloaded when it changes
+	APIKey                 string
+	APIKeyFile             string //path to a mounted file containing the API key, reloaded when it changes
+	ServiceToken           string //bearer token
+	CACertPath             string //PEM encoded CA bundle used to verify the cluster's certificate
+	ClientCertPath         string //PEM encoded client certificate for mTLS
+	ClientKeyPath          string //PEM encoded client key for mTLS
+	InsecureSkipVerify     bool   //disables certificate verification, only for development
+	CertificateFingerprint string //SHA256 hex fingerprint of the cluster's CA certificate
+	Transport              http.RoundTripper
+	Index                  string
+	RootNode               string
+	HashField              string //dotted path of a stored content hash
+	Filter                 filter.Filter
+	ParsedAvroSchema       avro.ParsedAvroSchema
+	Log                    logger.Log
 }
 
 func NewESClient(params ESParams) (*ESClient, error) {
-	cfg := elasticsearch.Config{
-		Addresses: []string{params.Url},
-		//Transport: &http.Transport{
-		//	TLSClientConfig: &tls.Config{
-		//		InsecureSkipVerify: false,
-		//	},
-		//},
+	transport, err := NewTransport(params)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
 	}
 
-	if params.Username != "" {
-		params.Log.Debug("Settings Elasticsearch username")
-		cfg.Username = params.Username
-	}
-	if params.Password != "" {
-		params.Log.Debug("Settings Elasticsearch password")
-		cfg.Password = params.Password
+	cfg := elasticsearch.Config{
+		Addresses: []string{params.Url},
+		Transport: transport,
 	}
 
 	client, err := elasticsearch.NewClient(cfg)
@@ -50,12 +59,19 @@ func NewESClient(params ESParams) (*ESClient, error) {
 	}
 
 	esClient := ESClient{
-		client:           client,
-		index:            params.Index,
-		rootNode:         params.RootNode,
-		parsedAvroSchema: params.ParsedAvroSchema,
-		log:              params.Log,
+		client:   client,
+		index:    params.Index,
+		rootNode: params.RootNode,
+		log:      params.Log,
+		filter:   params.Filter,
 	}
+	esClient.Queries = NewQueries(&esClient, QueryParams{
+		RootNode:         params.RootNode,
+		HashField:        params.HashField,
+		Filter:           params.Filter,
+		ParsedAvroSchema: params.ParsedAvroSchema,
+		Log:              params.Log,
+	})
 
 	return &esClient, nil
 }
@@ -0,0 +1,48 @@
+package elasticsearch
+
+import (
+	elasticsearch8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/go-errors/errors"
+
+	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
+)
+
+// ES8Client sends the requests built by Queries with the Elasticsearch 8.x client
+type ES8Client struct {
+	*Queries
+	client *elasticsearch8.TypedClient
+	index  string
+	log    logger.Log
+}
+
+func NewES8Client(params ESParams) (*ES8Client, error) {
+	transport, err := NewTransport(params)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	cfg := elasticsearch8.Config{
+		Addresses: []string{params.Url},
+		Transport: transport,
+	}
+
+	client, err := elasticsearch8.NewTypedClient(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	esClient := ES8Client{
+		client: client,
+		index:  params.Index,
+		log:    params.Log,
+	}
+	esClient.Queries = NewQueries(&esClient, QueryParams{
+		RootNode:         params.RootNode,
+		HashField:        params.HashField,
+		Filter:           params.Filter,
+		ParsedAvroSchema: params.ParsedAvroSchema,
+		Log:              params.Log,
+	})
+
+	return &esClient, nil
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	. "github.com/RedHatInsights/xjoin-validation/internal/record"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
 	"github.com/go-errors/errors"
 	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
@@ -15,11 +14,27 @@ func (e *ESClient) GetDocumentsByIDs(ids []string) (records []map[string]interfa
 	ctx, cancel := utils.DefaultContext()
 	defer cancel()
 
-	var query QueryIDsList
-	query.Query.Bool.Filter.IDs.Values = ids
-	reqJSON, err := json.Marshal(query)
+	query := e.DocumentsQuery(ids)
 	requestSize := len(ids)
 
+	if e.pitID != "" {
+		searchRes, err := e.searchPointInTime(map[string]interface{}{
+			"query": query,
+			"size":  requestSize,
+			"sort":  []string{"_id"},
+		})
+		if err != nil {
+			return records, errors.Wrap(err, 0)
+		}
+		byteValue, _ := ioutil.ReadAll(searchRes.Body)
+		return e.ParseDocuments(byteValue)
+	}
+
+	reqJSON, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+
 	searchReq := esapi.SearchRequest{
 		Index: []string{e.index},
 		Size:  &requestSize,
@@ -31,43 +46,18 @@ func (e *ESClient) GetDocumentsByIDs(ids []string) (records []map[string]interfa
 	if err != nil {
 		return records, errors.Wrap(err, 0)
 	}
+	byteValue, _ := ioutil.ReadAll(searchRes.Body)
 	if searchRes.StatusCode >= 400 {
-		bodyBytes, _ := ioutil.ReadAll(searchRes.Body)
-
 		return nil, errors.Wrap(errors.New(fmt.Sprintf(
 			"invalid response code when getting elasticsearch records by id. StatusCode: %v, Body: %s",
-			searchRes.StatusCode, bodyBytes)), 0)
-	}
-
-	records, err = e.parseSearchResponse(searchRes)
-	if err != nil {
-		return records, errors.Wrap(err, 0)
+			searchRes.StatusCode, byteValue)), 0)
 	}
 
-	return
-}
-
-func (e *ESClient) parseSearchResponse(res *esapi.Response) (records []map[string]interface{}, err error) {
-	var searchResponse SearchResponse
-	byteValue, _ := ioutil.ReadAll(res.Body)
-	err = json.Unmarshal(byteValue, &searchResponse)
+	records, err = e.ParseDocuments(byteValue)
 	if err != nil {
 		return records, errors.Wrap(err, 0)
 	}
 
-	for _, hit := range searchResponse.Hits.Hits {
-		recordParser := RecordParser{
-			Record:           hit.Source,
-			ParsedAvroSchema: e.parsedAvroSchema,
-		}
-		record, err := recordParser.Parse()
-		if err != nil {
-			return records, errors.Wrap(err, 0)
-		}
-
-		records = append(records, record)
-	}
-
 	return
 }
 
@@ -78,6 +68,7 @@ type SearchResponse struct {
 			Relation string `json:"relation"`
 		} `json:"total"`
 		Hits []struct {
+			ID     string                 `json:"_id"`
 			Source map[string]interface{} `json:"_source"`
 		} `json:"hits"`
 	} `json:"hits"`
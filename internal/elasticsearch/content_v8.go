@@ -0,0 +1,23 @@
+package elasticsearch
+
+import (
+	"github.com/go-errors/errors"
+)
+
+func (e *ES8Client) GetDocumentsByIDs(ids []string) (records []map[string]interface{}, err error) {
+	byteValue, err := e.Search(map[string]interface{}{
+		"query": e.DocumentsQuery(ids),
+		"size":  len(ids),
+		"sort":  []string{"_id"},
+	})
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+
+	records, err = e.ParseDocuments(byteValue)
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+
+	return
+}
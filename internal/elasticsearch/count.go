@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
@@ -14,9 +15,20 @@ type CountIDsResponse struct {
 }
 
 func (e *ESClient) CountIndex() (count int, err error) {
+	if e.pitID != "" {
+		return e.countPointInTime()
+	}
+
 	req := esapi.CountRequest{
 		Index: []string{e.index},
 	}
+	if query := e.CountQuery(); query != nil {
+		reqJSON, err := json.Marshal(map[string]interface{}{"query": query})
+		if err != nil {
+			return count, errors.Wrap(err, 0)
+		}
+		req.Body = bytes.NewReader(reqJSON)
+	}
 
 	e.log.Debug("Elasticsearch count request", "request", req)
 
@@ -0,0 +1,43 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+func (e *ES8Client) CountIndex() (count int, err error) {
+	e.log.Debug("Elasticsearch count request", "index", e.index)
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+
+	req := e.client.Count().Index(e.index)
+	if query := e.CountQuery(); query != nil {
+		reqJSON, err := json.Marshal(map[string]interface{}{"query": query})
+		if err != nil {
+			return count, errors.Wrap(err, 0)
+		}
+		req = req.Raw(bytes.NewReader(reqJSON))
+	}
+	res, err := req.Perform(ctx)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+	byteValue, err := readResponse(res, "counting index")
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	var countIDsResponse CountIDsResponse
+	err = json.Unmarshal(byteValue, &countIDsResponse)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	e.log.Debug("Elasticsearch count response", "body", countIDsResponse)
+
+	return countIDsResponse.Count, nil
+}
@@ -0,0 +1,61 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-errors/errors"
+)
+
+// GetDocumentHashesByIDs returns the stored content hash of each document by id. Requires the stored hash field.
+func (q *Queries) GetDocumentHashesByIDs(ids []string) (hashes map[string]string, err error) {
+	hashes = make(map[string]string)
+	if q.hashField == "" {
+		return hashes, errors.Wrap(errors.New("hash comparison requires a stored content hash field"), 0)
+	}
+
+	var query QueryIDsList
+	query.Query.Bool.Filter.IDs.Values = ids
+	body := map[string]interface{}{
+		"query":   query.Query,
+		"size":    len(ids),
+		"_source": []string{q.hashField},
+	}
+
+	byteValue, err := q.search(body)
+	if err != nil {
+		return hashes, errors.Wrap(err, 0)
+	}
+
+	return q.parseHashesResponse(byteValue)
+}
+
+func (q *Queries) parseHashesResponse(byteValue []byte) (hashes map[string]string, err error) {
+	hashes = make(map[string]string)
+
+	var searchResponse SearchResponse
+	err = json.Unmarshal(byteValue, &searchResponse)
+	if err != nil {
+		return hashes, errors.Wrap(err, 0)
+	}
+
+	for _, hit := range searchResponse.Hits.Hits {
+		hash, _ := fieldValue(hit.Source, q.hashField).(string)
+		hashes[hit.ID] = hash
+	}
+
+	return hashes, nil
+}
+
+// fieldValue returns the value at a dotted path in a document, or nil when the path does not exist
+func fieldValue(document map[string]interface{}, path string) interface{} {
+	var value interface{} = document
+	for _, key := range strings.Split(path, ".") {
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = nested[key]
+	}
+	return value
+}
@@ -8,61 +8,60 @@ import (
 	"github.com/go-errors/errors"
 	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
 	"io/ioutil"
-	"math"
 	"time"
 )
 
-func (e *ESClient) GetIDsByModifiedOn(start time.Time, end time.Time) (ids []string, err error) {
-	modifiedOnField := e.rootNode + ".modified_on" //TODO: parse modified_on field name from avro schema
-	reqJSON := []byte(fmt.Sprintf(`{"query":{"range":{"%s":{"lt":"%s","gt":"%s"}}}}`,
-		modifiedOnField, end.UTC().Format(time.RFC3339Nano), start.UTC().Format(time.RFC3339Nano)))
-
-	e.log.Debug("Elasticsearch GetIDsByModifiedOn query", "reqJson", string(reqJSON))
-
-	return e.getIDsQuery(e.index, reqJSON)
-}
-
-func (e *ESClient) GetIDsByIDList(ids []string) (responseIds []string, err error) {
-	chunkSize := float64(10000)
-	length := float64(len(ids))
-	numChunks := int(math.Ceil(length / chunkSize))
-
-	for i := 0; i < numChunks; i++ {
-		var query QueryIDsList
-
-		start := i * int(chunkSize)
-		end := ((i + 1) * int(chunkSize)) - 1
-		if len(ids) < end {
-			end = len(ids)
-		}
-
-		query.Query.Bool.Filter.IDs.Values = ids[start:end]
-		reqJSON, err := json.Marshal(query)
+// Search runs a search request in the point in time when one is open, otherwise in the index
+func (e *ESClient) Search(body map[string]interface{}) ([]byte, error) {
+	if e.pitID != "" {
+		searchRes, err := e.searchPointInTime(body)
 		if err != nil {
-			return responseIds, errors.Wrap(err, 0)
+			return nil, errors.Wrap(err, 0)
 		}
+		byteValue, _ := ioutil.ReadAll(searchRes.Body)
+		return byteValue, nil
+	}
 
-		idsChunk, err := e.getIDsQuery(e.index, reqJSON)
-		if err != nil {
-			return responseIds, errors.Wrap(err, 0)
-		}
-		responseIds = append(responseIds, idsChunk...)
+	reqJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
 	}
 
-	return
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	searchReq := esapi.SearchRequest{
+		Index: []string{e.index},
+		Body:  bytes.NewReader(reqJSON),
+	}
+	searchRes, err := searchReq.Do(ctx, e.client)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	byteValue, _ := ioutil.ReadAll(searchRes.Body)
+	if searchRes.StatusCode >= 400 {
+		return nil, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when searching elasticsearch. StatusCode: %v, Body: %s",
+			searchRes.StatusCode, byteValue)), 0)
+	}
+
+	return byteValue, nil
 }
 
-func (e *ESClient) getIDsQuery(index string, reqJSON []byte) (responseIds []string, err error) {
+// SearchIDs scrolls through the ids of the documents matched by reqJSON, or pages through them with search_after
+// when a point in time is open
+func (e *ESClient) SearchIDs(reqJSON []byte) (responseIds []string, err error) {
+	if e.pitID != "" {
+		return e.getIDsPointInTime(reqJSON)
+	}
+
 	size := new(int)
 	*size = 5000
 
-	idField := e.rootNode + ".id" //TODO: parse id field name from avro schema
-
 	searchReq := esapi.SearchRequest{
-		Index:  []string{index},
+		Index:  []string{e.index},
 		Scroll: time.Duration(1) * time.Minute,
 		Body:   bytes.NewReader(reqJSON),
-		Source: []string{idField},
+		Source: []string{e.IDField()},
 		Size:   size,
 		Sort:   []string{"_doc"},
 	}
@@ -0,0 +1,106 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/scroll"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// Search runs a search request in the index
+func (e *ES8Client) Search(body map[string]interface{}) ([]byte, error) {
+	reqJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	searchRes, err := e.client.Search().Index(e.index).Raw(bytes.NewReader(reqJSON)).Perform(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	return readResponse(searchRes, "searching elasticsearch")
+}
+
+// SearchIDs scrolls through the ids of the documents matched by reqJSON
+func (e *ES8Client) SearchIDs(reqJSON []byte) (responseIds []string, err error) {
+	var body map[string]interface{}
+	err = json.Unmarshal(reqJSON, &body)
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+	body["size"] = 5000
+	body["sort"] = []string{"_doc"}
+	body["_source"] = []string{e.IDField()}
+	reqJSON, err = json.Marshal(body)
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	searchRes, err := e.client.Search().Index(e.index).Scroll("1m").Raw(bytes.NewReader(reqJSON)).Perform(ctx)
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+	byteValue, err := readResponse(searchRes, "getting records ids")
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+
+	var searchJSON SearchIDsResponse
+	err = json.Unmarshal(byteValue, &searchJSON)
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+	for _, hit := range searchJSON.Hits.Hits {
+		responseIds = append(responseIds, hit.ID)
+	}
+
+	if searchJSON.Hits.Total.Value == 0 || searchJSON.ScrollID == "" {
+		return responseIds, nil
+	}
+
+	moreHits := true
+	scrollID := searchJSON.ScrollID
+
+	for moreHits {
+		ctx, cancel := utils.DefaultContext()
+		defer cancel()
+		scrollRes, err := e.client.Scroll().Request(&scroll.Request{Scroll: "1m", ScrollId: scrollID}).Do(ctx)
+		if err != nil {
+			return responseIds, errors.Wrap(err, 0)
+		}
+
+		for _, hit := range scrollRes.Hits.Hits {
+			responseIds = append(responseIds, hit.Id_)
+		}
+		if scrollRes.ScrollId_ != nil {
+			scrollID = *scrollRes.ScrollId_
+		}
+
+		if len(scrollRes.Hits.Hits) == 0 {
+			moreHits = false
+		}
+	}
+
+	return responseIds, nil
+}
+
+// readResponse returns the body of a response of the typed client, or an error with the body when it failed
+func readResponse(res *http.Response, action string) ([]byte, error) {
+	defer res.Body.Close()
+
+	byteValue, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 400 {
+		return nil, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when %s. StatusCode: %v, Body: %s", action, res.StatusCode, byteValue)), 0)
+	}
+	return byteValue, nil
+}
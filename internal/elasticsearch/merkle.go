@@ -0,0 +1,132 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/go-errors/errors"
+)
+
+const bucketHashMapScript = `if (doc[params.field].size() > 0) {
+  state.sum += Long.parseUnsignedLong(doc[params.field].value.substring(0, 16), 16);
+}`
+
+const bucketHashReduceScript = `long sum = 0L;
+for (s in states) {
+  if (s != null) {
+    sum += s;
+  }
+}
+return sum;`
+
+type bucketHashesResponse struct {
+	Aggregations struct {
+		Buckets struct {
+			Buckets map[string]struct {
+				DocCount int `json:"doc_count"`
+				Hash     struct {
+					Value json.Number `json:"value"`
+				} `json:"hash"`
+			} `json:"buckets"`
+		} `json:"buckets"`
+	} `json:"aggregations"`
+}
+
+// GetDocumentBucketHashes returns the number of documents not modified after modifiedBefore and the sum of their
+// hashes for each bucket of UUIDs that start with prefix, keyed by the child prefix of the bucket.
+// Requires the stored hash field.
+func (q *Queries) GetDocumentBucketHashes(prefix string, modifiedBefore time.Time) (buckets map[string]record.BucketHash, err error) {
+	buckets = make(map[string]record.BucketHash)
+	if q.hashField == "" {
+		return buckets, errors.Wrap(errors.New("range comparison requires a stored content hash field"), 0)
+	}
+	children := record.UUIDChildPrefixes(prefix)
+	if len(children) == 0 {
+		return buckets, errors.Wrap(errors.New("a whole id cannot be split into buckets: "+prefix), 0)
+	}
+
+	idField := q.IDField()
+	filters := make(map[string]interface{})
+	for _, child := range children {
+		filters[child] = map[string]interface{}{
+			"prefix": map[string]interface{}{idField: child},
+		}
+	}
+
+	//the sum of longs overflows, which is the sum modulo 2^64
+	byteValue, err := q.search(map[string]interface{}{
+		"size":  0,
+		"query": q.modifiedBeforeQuery(modifiedBefore),
+		"aggs": map[string]interface{}{
+			"buckets": map[string]interface{}{
+				"filters": map[string]interface{}{"filters": filters},
+				"aggs": map[string]interface{}{
+					"hash": map[string]interface{}{
+						"scripted_metric": map[string]interface{}{
+							"params":         map[string]interface{}{"field": q.hashField},
+							"init_script":    "state.sum = 0L",
+							"map_script":     bucketHashMapScript,
+							"combine_script": "return state.sum",
+							"reduce_script":  bucketHashReduceScript,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return buckets, errors.Wrap(err, 0)
+	}
+
+	var response bucketHashesResponse
+	err = json.Unmarshal(byteValue, &response)
+	if err != nil {
+		return buckets, errors.Wrap(err, 0)
+	}
+
+	for key, bucket := range response.Aggregations.Buckets.Buckets {
+		if bucket.DocCount == 0 {
+			continue
+		}
+
+		hash, err := strconv.ParseInt(bucket.Hash.Value.String(), 10, 64)
+		if err != nil {
+			return buckets, errors.Wrap(err, 0)
+		}
+		buckets[key] = record.BucketHash{
+			Count: bucket.DocCount,
+			Hash:  uint64(hash),
+		}
+	}
+
+	return buckets, nil
+}
+
+// GetDocumentHashesByIDPrefix returns the stored hash of at most limit documents not modified after modifiedBefore
+// whose id starts with prefix
+func (q *Queries) GetDocumentHashesByIDPrefix(prefix string, limit int, modifiedBefore time.Time) (hashes map[string]string, err error) {
+	if q.hashField == "" {
+		return hashes, errors.Wrap(errors.New("range comparison requires a stored content hash field"), 0)
+	}
+
+	idField := q.IDField()
+	byteValue, err := q.search(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"prefix": map[string]interface{}{idField: prefix}},
+					q.modifiedBeforeQuery(modifiedBefore),
+				},
+			},
+		},
+		"size":    limit,
+		"_source": []string{q.hashField},
+	})
+	if err != nil {
+		return hashes, errors.Wrap(err, 0)
+	}
+
+	return q.parseHashesResponse(byteValue)
+}
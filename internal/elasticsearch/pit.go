@@ -0,0 +1,218 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+const pointInTimeKeepAlive = "5m"
+
+type pointInTime struct {
+	ID string `json:"id"`
+}
+
+// OpenPointInTime opens a point in time on the index. Every search runs against the point in time until
+// ClosePointInTime is called, so each search reads the same view of the index.
+// The returned writeTime is the local time just before the point in time was opened, because Elasticsearch
+// does not return when the point in time was taken. Documents written after it are not visible, so the in flight
+// cutoff is only as accurate as the clock of the validator compared to the clock of the database.
+// Point in time requires Elasticsearch 7.12 or later.
+func (e *ESClient) OpenPointInTime() (writeTime time.Time, err error) {
+	if e.pitID != "" {
+		return writeTime, errors.Wrap(errors.New("a point in time is already open"), 0)
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("/%s/_pit?keep_alive=%s", url.PathEscape(e.index), pointInTimeKeepAlive), nil)
+	if err != nil {
+		return writeTime, errors.Wrap(err, 0)
+	}
+
+	writeTime = time.Now().UTC()
+	byteValue, err := e.perform(req)
+	if err != nil {
+		return writeTime, errors.Wrap(err, 0)
+	}
+
+	var pit pointInTime
+	err = json.Unmarshal(byteValue, &pit)
+	if err != nil {
+		return writeTime, errors.Wrap(err, 0)
+	}
+	if pit.ID == "" {
+		return writeTime, errors.Wrap(errors.New("open point in time response did not contain an id"), 0)
+	}
+	e.pitID = pit.ID
+
+	e.log.Debug("Opened Elasticsearch point in time", "writeTime", writeTime)
+
+	return writeTime, nil
+}
+
+func (e *ESClient) ClosePointInTime() error {
+	if e.pitID == "" {
+		return nil
+	}
+
+	reqJSON, err := json.Marshal(pointInTime{ID: e.pitID})
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	e.pitID = ""
+
+	req, err := http.NewRequest(http.MethodDelete, "/_pit", bytes.NewReader(reqJSON))
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = e.perform(req)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
+
+// perform sends a request that is not supported by the esapi package of this client version
+func (e *ESClient) perform(req *http.Request) (byteValue []byte, err error) {
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+
+	res, err := e.client.Perform(req.WithContext(ctx))
+	if err != nil {
+		return byteValue, errors.Wrap(err, 0)
+	}
+	defer res.Body.Close()
+
+	byteValue, _ = ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 300 {
+		return byteValue, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code for %s %s. StatusCode: %v, Body: %s",
+			req.Method, req.URL.Path, res.StatusCode, byteValue)), 0)
+	}
+	return byteValue, nil
+}
+
+// searchPointInTime adds the point in time to the search body and runs the search without an index
+func (e *ESClient) searchPointInTime(body map[string]interface{}) (*esapi.Response, error) {
+	body["pit"] = map[string]interface{}{
+		"id":         e.pitID,
+		"keep_alive": pointInTimeKeepAlive,
+	}
+
+	reqJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	searchReq := esapi.SearchRequest{
+		Body: bytes.NewReader(reqJSON),
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	searchRes, err := searchReq.Do(ctx, e.client)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	if searchRes.StatusCode >= 400 {
+		bodyBytes, _ := ioutil.ReadAll(searchRes.Body)
+
+		return nil, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when searching point in time. StatusCode: %v, Body: %s",
+			searchRes.StatusCode, bodyBytes)), 0)
+	}
+
+	return searchRes, nil
+}
+
+func (e *ESClient) countPointInTime() (count int, err error) {
+	body := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+	}
+	if len(e.filter) > 0 {
+		body["query"] = e.filter.Query(nil, e.rootNode)
+	}
+
+	searchRes, err := e.searchPointInTime(body)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	var searchJSON SearchIDsResponse
+	byteValue, _ := ioutil.ReadAll(searchRes.Body)
+	err = json.Unmarshal(byteValue, &searchJSON)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	return searchJSON.Hits.Total.Value, nil
+}
+
+type pointInTimeIDsResponse struct {
+	Hits struct {
+		Hits []struct {
+			ID   string        `json:"_id"`
+			Sort []interface{} `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+	PitID string `json:"pit_id"`
+}
+
+// getIDsPointInTime pages through the query results with search_after instead of a scroll
+func (e *ESClient) getIDsPointInTime(reqJSON []byte) (ids []string, err error) {
+	var query map[string]interface{}
+	err = json.Unmarshal(reqJSON, &query)
+	if err != nil {
+		return ids, errors.Wrap(err, 0)
+	}
+
+	idField := e.rootNode + ".id" //TODO: parse id field name from avro schema
+	var searchAfter []interface{}
+
+	for {
+		body := map[string]interface{}{
+			"query":   query["query"],
+			"size":    5000,
+			"sort":    []string{"_shard_doc"},
+			"_source": []string{idField},
+		}
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+
+		searchRes, err := e.searchPointInTime(body)
+		if err != nil {
+			return ids, errors.Wrap(err, 0)
+		}
+
+		var searchJSON pointInTimeIDsResponse
+		byteValue, _ := ioutil.ReadAll(searchRes.Body)
+		err = json.Unmarshal(byteValue, &searchJSON)
+		if err != nil {
+			return ids, errors.Wrap(err, 0)
+		}
+		if searchJSON.PitID != "" {
+			e.pitID = searchJSON.PitID
+		}
+
+		if len(searchJSON.Hits.Hits) == 0 {
+			return ids, nil
+		}
+		for _, hit := range searchJSON.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		searchAfter = searchJSON.Hits.Hits[len(searchJSON.Hits.Hits)-1].Sort
+	}
+}
@@ -0,0 +1,201 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
+	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
+	. "github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// idsChunkSize is the number of ids requested per search, the default index.max_result_window
+const idsChunkSize = 10000
+
+// Searcher sends the search requests built by Queries with the API of one client
+type Searcher interface {
+	// Search runs a search request with the body and returns the response body
+	Search(body map[string]interface{}) ([]byte, error)
+	// SearchIDs returns the ids of every document matched by the JSON request body, scrolling through the results
+	SearchIDs(reqJSON []byte) ([]string, error)
+}
+
+type QueryParams struct {
+	RootNode         string
+	HashField        string //dotted path of a stored content hash
+	Filter           filter.Filter
+	ParsedAvroSchema avro.ParsedAvroSchema
+	Log              logger.Log
+}
+
+// Queries builds the requests of an index store and parses their responses. It is shared by the Elasticsearch 7,
+// Elasticsearch 8 and OpenSearch clients, which only differ in how the requests are sent.
+type Queries struct {
+	searcher         Searcher
+	rootNode         string
+	hashField        string
+	filter           filter.Filter
+	parsedAvroSchema avro.ParsedAvroSchema
+	log              logger.Log
+}
+
+func NewQueries(searcher Searcher, params QueryParams) *Queries {
+	return &Queries{
+		searcher:         searcher,
+		rootNode:         params.RootNode,
+		hashField:        params.HashField,
+		filter:           params.Filter,
+		parsedAvroSchema: params.ParsedAvroSchema,
+		log:              params.Log,
+	}
+}
+
+func (q *Queries) GetIDsByModifiedOn(start time.Time, end time.Time) (ids []string, err error) {
+	modifiedOnField := q.rootNode + ".modified_on" //TODO: parse modified_on field name from avro schema
+	reqJSON := []byte(fmt.Sprintf(`{"query":{"range":{"%s":{"lt":"%s","gt":"%s"}}}}`,
+		modifiedOnField, end.UTC().Format(time.RFC3339Nano), start.UTC().Format(time.RFC3339Nano)))
+
+	q.log.Debug("Index GetIDsByModifiedOn query", "reqJson", string(reqJSON))
+
+	return q.searchIDs(reqJSON)
+}
+
+func (q *Queries) GetIDsByIDList(ids []string) (responseIds []string, err error) {
+	for start := 0; start < len(ids); start += idsChunkSize {
+		var query QueryIDsList
+		query.Query.Bool.Filter.IDs.Values = ids[start:utils.Min(start+idsChunkSize, len(ids))]
+		reqJSON, err := json.Marshal(query)
+		if err != nil {
+			return responseIds, errors.Wrap(err, 0)
+		}
+
+		idsChunk, err := q.searchIDs(reqJSON)
+		if err != nil {
+			return responseIds, errors.Wrap(err, 0)
+		}
+		responseIds = append(responseIds, idsChunk...)
+	}
+
+	return
+}
+
+// GetIDsByIDRange returns the ids > afterID and <= lastID of the documents not modified after modifiedBefore.
+// An empty id leaves that side of the range open.
+func (q *Queries) GetIDsByIDRange(afterID string, lastID string, modifiedBefore time.Time) (ids []string, err error) {
+	idRange := make(map[string]string)
+	if afterID != "" {
+		idRange["gt"] = afterID
+	}
+	if lastID != "" {
+		idRange["lte"] = lastID
+	}
+
+	reqJSON, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"range": map[string]interface{}{q.IDField(): idRange}},
+					q.modifiedBeforeQuery(modifiedBefore),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return ids, errors.Wrap(err, 0)
+	}
+
+	q.log.Debug("Index GetIDsByIDRange query", "reqJson", string(reqJSON))
+
+	return q.searchIDs(reqJSON)
+}
+
+// modifiedBeforeQuery matches the documents not modified after modifiedBefore
+func (q *Queries) modifiedBeforeQuery(modifiedBefore time.Time) map[string]interface{} {
+	modifiedOnField := q.rootNode + ".modified_on" //TODO: parse modified_on field name from avro schema
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			modifiedOnField: map[string]string{"lte": modifiedBefore.UTC().Format(time.RFC3339Nano)},
+		},
+	}
+}
+
+// IDField is the field under the root node the ids are read from
+func (q *Queries) IDField() string {
+	return q.rootNode + ".id" //TODO: parse id field name from avro schema
+}
+
+// DocumentsQuery returns the query of the documents with ids that match the row filter
+func (q *Queries) DocumentsQuery(ids []string) interface{} {
+	var query QueryIDsList
+	query.Query.Bool.Filter.IDs.Values = ids
+	return q.filter.Query(query.Query, q.rootNode)
+}
+
+// CountQuery returns the query of the documents that match the row filter, nil when every document matches
+func (q *Queries) CountQuery() interface{} {
+	return q.filter.Query(nil, q.rootNode)
+}
+
+// ParseDocuments parses the documents of a search response body. The documents that cannot be parsed are
+// returned as ParseErrors with the other records.
+func (q *Queries) ParseDocuments(byteValue []byte) (records []map[string]interface{}, err error) {
+	var searchResponse SearchResponse
+	err = json.Unmarshal(byteValue, &searchResponse)
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+
+	var parseErrors ParseErrors
+	for _, hit := range searchResponse.Hits.Hits {
+		recordParser := RecordParser{
+			Record:           hit.Source,
+			ParsedAvroSchema: q.parsedAvroSchema,
+		}
+		record, err := recordParser.Parse()
+		if fieldError, ok := AsFieldParseError(err); ok {
+			parseErrors = append(parseErrors, fieldError)
+			continue
+		} else if err != nil {
+			return records, errors.Wrap(err, 0)
+		}
+
+		records = append(records, record)
+	}
+
+	if len(parseErrors) > 0 {
+		return records, errors.Wrap(parseErrors, 0)
+	}
+
+	return records, nil
+}
+
+// searchIDs restricts the request body to the row filter and returns the ids of the documents it matches
+func (q *Queries) searchIDs(reqJSON []byte) ([]string, error) {
+	reqJSON, err := q.filter.Body(reqJSON, q.rootNode)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	ids, err := q.searcher.SearchIDs(reqJSON)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	return ids, nil
+}
+
+// search restricts the query of the body to the row filter and runs the search
+func (q *Queries) search(body map[string]interface{}) ([]byte, error) {
+	if query := q.filter.Query(body["query"], q.rootNode); query != nil {
+		body["query"] = query
+	}
+
+	byteValue, err := q.searcher.Search(body)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	return byteValue, nil
+}
@@ -0,0 +1,114 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// tenantsPageSize is the number of composite buckets requested per page, below the default search.max_buckets
+const tenantsPageSize = 10000
+
+type tenantCountsResponse struct {
+	Aggregations struct {
+		Tenants struct {
+			AfterKey map[string]interface{} `json:"after_key"`
+			Buckets  []struct {
+				Key      map[string]interface{} `json:"key"`
+				DocCount int                    `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"tenants"`
+	} `json:"aggregations"`
+}
+
+// CountIndexByTenant counts the documents of each value of the tenant field under the root node. The tenants are
+// paged with a composite aggregation so none are left out, and documents without the field are counted as an empty
+// tenant.
+func (q *Queries) CountIndexByTenant(field string) (counts map[string]int, err error) {
+	counts = make(map[string]int)
+
+	var afterKey map[string]interface{}
+	for {
+		composite := map[string]interface{}{
+			"size": tenantsPageSize,
+			"sources": []map[string]interface{}{{
+				"tenant": map[string]interface{}{
+					"terms": map[string]interface{}{
+						"field":          q.rootNode + "." + field,
+						"missing_bucket": true,
+					},
+				},
+			}},
+		}
+		if afterKey != nil {
+			composite["after"] = afterKey
+		}
+
+		byteValue, err := q.search(map[string]interface{}{
+			"size": 0,
+			"aggs": map[string]interface{}{
+				"tenants": map[string]interface{}{"composite": composite},
+			},
+		})
+		if err != nil {
+			return counts, errors.Wrap(err, 0)
+		}
+
+		var response tenantCountsResponse
+		err = json.Unmarshal(byteValue, &response)
+		if err != nil {
+			return counts, errors.Wrap(err, 0)
+		}
+
+		for _, bucket := range response.Aggregations.Tenants.Buckets {
+			counts[tenantString(bucket.Key["tenant"])] += bucket.DocCount
+		}
+
+		afterKey = response.Aggregations.Tenants.AfterKey
+		if len(response.Aggregations.Tenants.Buckets) < tenantsPageSize || afterKey == nil {
+			return counts, nil
+		}
+	}
+}
+
+// GetDocumentTenantsByIDs returns the value of the tenant field of each document by id
+func (q *Queries) GetDocumentTenantsByIDs(field string, ids []string) (tenants map[string]string, err error) {
+	tenants = make(map[string]string)
+
+	field = q.rootNode + "." + field
+	for start := 0; start < len(ids); start += idsChunkSize {
+		chunk := ids[start:utils.Min(start+idsChunkSize, len(ids))]
+
+		var query QueryIDsList
+		query.Query.Bool.Filter.IDs.Values = chunk
+		byteValue, err := q.search(map[string]interface{}{
+			"query":   query.Query,
+			"size":    len(chunk),
+			"_source": []string{field},
+		})
+		if err != nil {
+			return tenants, errors.Wrap(err, 0)
+		}
+
+		var searchResponse SearchResponse
+		err = json.Unmarshal(byteValue, &searchResponse)
+		if err != nil {
+			return tenants, errors.Wrap(err, 0)
+		}
+
+		for _, hit := range searchResponse.Hits.Hits {
+			tenants[hit.ID] = tenantString(fieldValue(hit.Source, field))
+		}
+	}
+
+	return tenants, nil
+}
+
+func tenantString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,244 @@
+package elasticsearch
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+// NewTransport builds the http transport used by the index clients. It applies the TLS options from params and sets
+// the Authorization header on each request from either static credentials or credentials read from mounted files.
+func NewTransport(params ESParams) (http.RoundTripper, error) {
+	base := params.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if params.CACertPath != "" || params.ClientCertPath != "" || params.InsecureSkipVerify ||
+		params.CertificateFingerprint != "" {
+		tlsConfig, err := newTLSConfig(params)
+		if err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+
+		var transport *http.Transport
+		if defaultTransport, ok := base.(*http.Transport); ok {
+			transport = defaultTransport.Clone()
+		} else {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+		transport.TLSClientConfig = tlsConfig
+		base = transport
+	}
+
+	return &authTransport{
+		base:         base,
+		username:     newSecret(params.Username, params.UsernameFile),
+		password:     newSecret(params.Password, params.PasswordFile),
+		apiKey:       newSecret(params.APIKey, params.APIKeyFile),
+		serviceToken: newSecret(params.ServiceToken, ""),
+	}, nil
+}
+
+func newTLSConfig(params ESParams) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: params.InsecureSkipVerify,
+	}
+
+	if params.CACertPath != "" {
+		caCert, err := os.ReadFile(params.CACertPath)
+		if err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.Wrap(errors.New("no certificates found in CA bundle "+params.CACertPath), 0)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if params.ClientCertPath != "" {
+		clientCert := &clientCertificate{certPath: params.ClientCertPath, keyPath: params.ClientKeyPath}
+		if _, err := clientCert.get(nil); err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+		tlsConfig.GetClientCertificate = clientCert.get
+	}
+
+	if params.CertificateFingerprint != "" {
+		//without a CA bundle the pinned certificate is the only trusted root, it replaces the system roots
+		if params.CACertPath == "" {
+			tlsConfig.InsecureSkipVerify = true
+		}
+		tlsConfig.VerifyConnection = verifyFingerprint(params.CertificateFingerprint)
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyFingerprint accepts the connection when the certificate chain presented by the server, and its host name,
+// verify with the certificate matching the SHA256 fingerprint as the only root. Presenting the pinned certificate
+// alongside an unrelated leaf certificate is not enough.
+func verifyFingerprint(fingerprint string) func(state tls.ConnectionState) error {
+	expected := strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("elasticsearch did not present a certificate")
+		}
+
+		for _, cert := range state.PeerCertificates {
+			digest := sha256.Sum256(cert.Raw)
+			if hex.EncodeToString(digest[:]) != expected {
+				continue
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(cert)
+			intermediates := x509.NewCertPool()
+			for _, intermediate := range state.PeerCertificates[1:] {
+				intermediates.AddCert(intermediate)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       state.ServerName,
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			if err != nil {
+				return errors.Wrap(err, 0)
+			}
+			return nil
+		}
+		return errors.New("no certificate presented by elasticsearch matches the configured fingerprint")
+	}
+}
+
+// clientCertificate reloads the client certificate for mTLS when either file is modified, e.g. when rotated
+type clientCertificate struct {
+	certPath string
+	keyPath  string
+	modTime  time.Time
+	cert     *tls.Certificate
+	mutex    sync.Mutex
+}
+
+func (c *clientCertificate) get(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	modTime, err := latestModTime(c.certPath, c.keyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	if c.cert == nil || modTime.After(c.modTime) {
+		cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+		c.cert = &cert
+		c.modTime = modTime
+	}
+
+	return c.cert, nil
+}
+
+// secret is a credential that is either static or read from a mounted file and reloaded when the file changes
+type secret struct {
+	value   string
+	path    string
+	modTime time.Time
+	mutex   sync.Mutex
+}
+
+func newSecret(value string, path string) *secret {
+	return &secret{value: value, path: path}
+}
+
+func (s *secret) get() (string, error) {
+	if s.path == "" {
+		return s.value, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	modTime, err := latestModTime(s.path)
+	if err != nil {
+		return "", errors.Wrap(err, 0)
+	}
+
+	if modTime.After(s.modTime) {
+		value, err := os.ReadFile(s.path)
+		if err != nil {
+			return "", errors.Wrap(err, 0)
+		}
+		s.value = strings.TrimSpace(string(value))
+		s.modTime = modTime
+	}
+
+	return s.value, nil
+}
+
+func latestModTime(paths ...string) (modTime time.Time, err error) {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTime, errors.Wrap(err, 0)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return
+}
+
+type authTransport struct {
+	base         http.RoundTripper
+	username     *secret
+	password     *secret
+	apiKey       *secret
+	serviceToken *secret
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiKey, err := t.apiKey.get()
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	serviceToken, err := t.serviceToken.get()
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	username, err := t.username.get()
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	password, err := t.password.get()
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	//RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	if apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+	} else if serviceToken != "" {
+		req.Header.Set("Authorization", "Bearer "+serviceToken)
+	} else if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	return t.base.RoundTrip(req)
+}
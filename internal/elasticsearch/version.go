@@ -0,0 +1,64 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+type clusterInfoResponse struct {
+	Version struct {
+		Number string `json:"number"`
+	} `json:"version"`
+}
+
+// DetectMajorVersion reads version.number from the cluster's root endpoint, e.g. 8 for "8.9.0"
+func DetectMajorVersion(params ESParams) (major int, err error) {
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(params.Url, "/")+"/", nil)
+	if err != nil {
+		return major, errors.Wrap(err, 0)
+	}
+
+	transport, err := NewTransport(params)
+	if err != nil {
+		return major, errors.Wrap(err, 0)
+	}
+
+	client := http.Client{Transport: transport}
+	res, err := client.Do(req)
+	if err != nil {
+		return major, errors.Wrap(err, 0)
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 300 {
+		return major, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when detecting elasticsearch version. StatusCode: %v, Body: %s",
+			res.StatusCode, body)), 0)
+	}
+
+	var info clusterInfoResponse
+	err = json.Unmarshal(body, &info)
+	if err != nil {
+		return major, errors.Wrap(err, 0)
+	}
+
+	major, err = strconv.Atoi(strings.Split(info.Version.Number, ".")[0])
+	if err != nil {
+		return major, errors.Wrap(fmt.Errorf("unable to parse elasticsearch version.number %q: %w", info.Version.Number, err), 0)
+	}
+
+	params.Log.Debug("Detected Elasticsearch version", "version", info.Version.Number)
+
+	return major, nil
+}
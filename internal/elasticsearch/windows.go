@@ -0,0 +1,63 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+type windowCountsResponse struct {
+	Aggregations struct {
+		Windows struct {
+			Buckets []struct {
+				Key      int64 `json:"key"`
+				DocCount int   `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"windows"`
+	} `json:"aggregations"`
+}
+
+// CountIndexByWindow counts the documents in each interval (minute, hour or day) of the timestamp field under the
+// root node between start and end with a date_histogram, keyed by the UTC start of each window
+func (q *Queries) CountIndexByWindow(field string, interval string, start time.Time, end time.Time) (counts map[time.Time]int, err error) {
+	counts = make(map[time.Time]int)
+
+	field = q.rootNode + "." + field
+	byteValue, err := q.search(map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				field: map[string]interface{}{
+					"gte": start.UTC().Format(time.RFC3339Nano),
+					"lt":  end.UTC().Format(time.RFC3339Nano),
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"windows": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             field,
+					"calendar_interval": interval,
+					"min_doc_count":     1,
+					"time_zone":         "UTC",
+				},
+			},
+		},
+	})
+	if err != nil {
+		return counts, errors.Wrap(err, 0)
+	}
+
+	var response windowCountsResponse
+	err = json.Unmarshal(byteValue, &response)
+	if err != nil {
+		return counts, errors.Wrap(err, 0)
+	}
+
+	for _, bucket := range response.Aggregations.Windows.Buckets {
+		counts[time.UnixMilli(bucket.Key).UTC()] = bucket.DocCount
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-errors/errors"
+)
+
+const (
+	OperatorEqual        = "eq"
+	OperatorNotEqual     = "ne"
+	OperatorIn           = "in"
+	OperatorNotIn        = "not_in"
+	OperatorGreater      = "gt"
+	OperatorGreaterEqual = "gte"
+	OperatorLess         = "lt"
+	OperatorLessEqual    = "lte"
+	OperatorIsNull       = "is_null"
+	OperatorIsNotNull    = "is_not_null"
+)
+
+var fieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsField returns whether name is a plain column name, which can be used in SQL and as a field of the root node
+func IsField(name string) bool {
+	return fieldPattern.MatchString(name)
+}
+
+// Condition compares a column of the table, which is the field <root node>.<field> in the index, with a value
+type Condition struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value,omitempty"`
+}
+
+// Filter is the conditions a row has to match to be in the scope of the index. The conditions are combined with AND.
+type Filter []Condition
+
+// Parse parses a JSON list of conditions, e.g. [{"field": "deleted", "operator": "ne", "value": true}].
+// An empty value is an empty filter.
+func Parse(value string) (filter Filter, err error) {
+	if strings.TrimSpace(value) == "" {
+		return filter, nil
+	}
+
+	err = json.Unmarshal([]byte(value), &filter)
+	if err != nil {
+		return filter, errors.Wrap(err, 0)
+	}
+
+	for _, condition := range filter {
+		if !IsField(condition.Field) {
+			return filter, errors.Wrap(errors.New("invalid filter field: "+condition.Field), 0)
+		}
+
+		switch condition.Operator {
+		case OperatorEqual, OperatorNotEqual, OperatorGreater, OperatorGreaterEqual, OperatorLess, OperatorLessEqual:
+			if _, ok := condition.Value.([]interface{}); ok || condition.Value == nil {
+				return filter, errors.Wrap(fmt.Errorf(
+					"the %s operator requires a single value for field %s", condition.Operator, condition.Field), 0)
+			}
+		case OperatorIn, OperatorNotIn:
+			if values, ok := condition.Value.([]interface{}); !ok || len(values) == 0 {
+				return filter, errors.Wrap(fmt.Errorf(
+					"the %s operator requires a list of values for field %s", condition.Operator, condition.Field), 0)
+			}
+		case OperatorIsNull, OperatorIsNotNull:
+		default:
+			return filter, errors.Wrap(errors.New("invalid filter operator: "+condition.Operator), 0)
+		}
+	}
+
+	return filter, nil
+}
+
+// SQLDialect is the syntax of the filter that differs between the source databases
+type SQLDialect interface {
+	// QuoteString returns value as a string literal
+	QuoteString(value string) string
+
+	// NotEqual compares left and right so that NULL is not equal to a value
+	NotEqual(left string, right string) string
+}
+
+// SQL returns the filter as a SQL boolean expression, or an empty string when the filter is empty.
+// The negative operators also match NULL, like a must_not query matches documents without the field.
+func (f Filter) SQL(dialect SQLDialect) string {
+	var conditions []string
+	for _, condition := range f {
+		var sql string
+		switch condition.Operator {
+		case OperatorEqual:
+			sql = fmt.Sprintf("%s = %s", condition.Field, sqlValue(dialect, condition.Value))
+		case OperatorNotEqual:
+			sql = dialect.NotEqual(condition.Field, sqlValue(dialect, condition.Value))
+		case OperatorIn:
+			sql = fmt.Sprintf("%s IN (%s)", condition.Field, sqlValues(dialect, condition.Value))
+		case OperatorNotIn:
+			sql = fmt.Sprintf("(%s IS NULL OR %s NOT IN (%s))", condition.Field, condition.Field, sqlValues(dialect, condition.Value))
+		case OperatorGreater:
+			sql = fmt.Sprintf("%s > %s", condition.Field, sqlValue(dialect, condition.Value))
+		case OperatorGreaterEqual:
+			sql = fmt.Sprintf("%s >= %s", condition.Field, sqlValue(dialect, condition.Value))
+		case OperatorLess:
+			sql = fmt.Sprintf("%s < %s", condition.Field, sqlValue(dialect, condition.Value))
+		case OperatorLessEqual:
+			sql = fmt.Sprintf("%s <= %s", condition.Field, sqlValue(dialect, condition.Value))
+		case OperatorIsNull:
+			sql = fmt.Sprintf("%s IS NULL", condition.Field)
+		case OperatorIsNotNull:
+			sql = fmt.Sprintf("%s IS NOT NULL", condition.Field)
+		}
+		conditions = append(conditions, sql)
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func sqlValue(dialect SQLDialect, value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return dialect.QuoteString(typedValue)
+	case bool:
+		if typedValue {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprintf("%v", typedValue)
+	}
+}
+
+func sqlValues(dialect SQLDialect, value interface{}) string {
+	var values []string
+	for _, v := range value.([]interface{}) {
+		values = append(values, sqlValue(dialect, v))
+	}
+	return strings.Join(values, ", ")
+}
+
+// Clauses returns the filter as Elasticsearch query clauses on the fields under rootNode
+func (f Filter) Clauses(rootNode string) (clauses []interface{}) {
+	for _, condition := range f {
+		field := rootNode + "." + condition.Field
+		var clause map[string]interface{}
+		switch condition.Operator {
+		case OperatorEqual:
+			clause = term("term", field, condition.Value)
+		case OperatorNotEqual:
+			clause = mustNot(term("term", field, condition.Value))
+		case OperatorIn:
+			clause = term("terms", field, condition.Value)
+		case OperatorNotIn:
+			clause = mustNot(term("terms", field, condition.Value))
+		case OperatorGreater, OperatorGreaterEqual, OperatorLess, OperatorLessEqual:
+			clause = term("range", field, map[string]interface{}{condition.Operator: condition.Value})
+		case OperatorIsNull:
+			clause = mustNot(term("exists", "field", field))
+		case OperatorIsNotNull:
+			clause = term("exists", "field", field)
+		}
+		clauses = append(clauses, clause)
+	}
+	return
+}
+
+func term(queryType string, field string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{queryType: map[string]interface{}{field: value}}
+}
+
+func mustNot(clause map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{"must_not": []interface{}{clause}}}
+}
+
+// Query returns an Elasticsearch query that only matches the documents of query that match the filter.
+// A nil query matches every document. The query is returned unchanged when the filter is empty.
+func (f Filter) Query(query interface{}, rootNode string) interface{} {
+	if len(f) == 0 {
+		return query
+	}
+
+	boolQuery := map[string]interface{}{"filter": f.Clauses(rootNode)}
+	if query != nil {
+		boolQuery["must"] = []interface{}{query}
+	}
+	return map[string]interface{}{"bool": boolQuery}
+}
+
+// Body applies Query to the query of a JSON request body
+func (f Filter) Body(body []byte, rootNode string) ([]byte, error) {
+	if len(f) == 0 {
+		return body, nil
+	}
+
+	var request map[string]interface{}
+	err := json.Unmarshal(body, &request)
+	if err != nil {
+		return body, errors.Wrap(err, 0)
+	}
+	if request == nil {
+		request = make(map[string]interface{})
+	}
+
+	request["query"] = f.Query(request["query"], rootNode)
+
+	body, err = json.Marshal(request)
+	if err != nil {
+		return body, errors.Wrap(err, 0)
+	}
+	return body, nil
+}
@@ -0,0 +1,300 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	runsBucket        = []byte("runs")
+	checkpointsBucket = []byte("checkpoints")
+)
+
+// Run is the persisted result of one validation of an index
+type Run struct {
+	ID            uint64                          `json:"id"`
+	Attempt       int                             `json:"attempt"`    //number of the attempt of the validation, starting at 0
+	Invocation    time.Time                       `json:"invocation"` //start of the first attempt, shared by the attempts of one validation
+	Index         string                          `json:"index"`
+	Table         string                          `json:"table"`
+	StartedAt     time.Time                       `json:"startedAt"`
+	FinishedAt    time.Time                       `json:"finishedAt"`
+	Result        validation.ValidationResult     `json:"result"`
+	Reason        string                          `json:"reason,omitempty"`
+	Message       string                          `json:"message,omitempty"`
+	Details       validator.ResponseDetails       `json:"details"`
+	Mismatches    validator.CategorizedMismatches `json:"mismatches,omitempty"` //the mismatched ids, at most the maxMismatches of Save
+	MismatchCount int                             `json:"mismatchCount"`        //number of mismatched ids, including those not saved
+	IDsChecked    bool                            `json:"idsChecked"`           //false when the validation stopped before comparing the ids
+}
+
+// Store persists validation runs in a local BoltDB file
+type Store struct {
+	db *bolt.DB
+}
+
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(checkpointsBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, 0)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	err := s.db.Close()
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
+
+// NewRun builds a Run from a validation response
+func NewRun(response validator.ValidationResponse, index string, table string, startedAt time.Time) Run {
+	return Run{
+		Index:         index,
+		Table:         table,
+		Invocation:    startedAt,
+		StartedAt:     startedAt,
+		FinishedAt:    time.Now().UTC(),
+		Result:        response.Result,
+		Reason:        response.Reason,
+		Message:       response.Message,
+		Details:       response.Details,
+		Mismatches:    response.Mismatches,
+		MismatchCount: len(response.Mismatches),
+		IDsChecked:    response.Mismatches != nil,
+	}
+}
+
+// Save stores the run with the next run id. Only the first maxMismatches mismatched ids in sort order are stored, so
+// runs with a large number of mismatches do not grow the file without bound. maxMismatches 0 stores every id.
+func (s *Store) Save(run Run, maxMismatches int) (saved Run, err error) {
+	if maxMismatches > 0 && len(run.Mismatches) > maxMismatches {
+		ids := make([]string, 0, len(run.Mismatches))
+		for id := range run.Mismatches {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		mismatches := make(validator.CategorizedMismatches, maxMismatches)
+		for _, id := range ids[:maxMismatches] {
+			mismatches[id] = run.Mismatches[id]
+		}
+		run.Mismatches = mismatches
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		run.ID = id
+
+		value, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(runKey(id), value)
+	})
+	if err != nil {
+		return saved, errors.Wrap(err, 0)
+	}
+
+	return run, nil
+}
+
+// Prune deletes the oldest runs so only the newest keep runs remain. keep 0 keeps every run.
+func (s *Store) Prune(keep int) (deleted int, err error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		excess := bucket.Stats().KeyN - keep
+
+		//the keys are collected first, deleting while iterating a cursor skips keys
+		var oldest [][]byte
+		cursor := bucket.Cursor()
+		for key, _ := cursor.First(); key != nil && len(oldest) < excess; key, _ = cursor.Next() {
+			oldest = append(oldest, append([]byte(nil), key...))
+		}
+		for _, key := range oldest {
+			err := bucket.Delete(key)
+			if err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, errors.Wrap(err, 0)
+	}
+
+	return
+}
+
+// List returns the newest runs first. An empty index lists the runs of every index, limit 0 lists every run.
+func (s *Store) List(index string, limit int) (runs []Run, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(runsBucket).Cursor()
+		for key, value := cursor.Last(); key != nil; key, value = cursor.Prev() {
+			var run Run
+			err := json.Unmarshal(value, &run)
+			if err != nil {
+				return err
+			}
+
+			if index != "" && run.Index != index {
+				continue
+			}
+			runs = append(runs, run)
+
+			if limit > 0 && len(runs) == limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return runs, errors.Wrap(err, 0)
+	}
+
+	return
+}
+
+// LoadCheckpoint returns the incremental validation checkpoint, or an empty checkpoint before the first run
+func (s *Store) LoadCheckpoint(key string) (checkpoint validator.Checkpoint, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(checkpointsBucket).Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+		return json.Unmarshal(value, &checkpoint)
+	})
+	if err != nil {
+		return checkpoint, errors.Wrap(err, 0)
+	}
+	return
+}
+
+func (s *Store) SaveCheckpoint(checkpoint validator.Checkpoint) error {
+	value, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(checkpointsBucket).Put([]byte(checkpoint.Key), value)
+	})
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
+
+// runKey is big endian so the runs are iterated in the order they were saved
+func runKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// PersistentMismatches finds the ids of run that also mismatched in each of the previous runs-1 validations of the
+// index. Only the final attempt of each earlier validation is compared, and validations that stopped before comparing
+// the ids, e.g. on a count mismatch, are skipped. In flight mismatches are caused by lag and are always transient, and
+// so are the ids that were not saved with a previous run.
+func (s *Store) PersistentMismatches(run Run, runs int) (persistent validator.PersistentMismatches, err error) {
+	persistent.Runs = runs
+
+	var previousRuns []Run
+	if runs > 1 {
+		previousRuns, err = s.previousValidations(run, runs-1)
+		if err != nil {
+			return persistent, errors.Wrap(err, 0)
+		}
+	}
+
+	var ids []string
+	for id, category := range run.Mismatches {
+		if category == validator.CategoryInFlight {
+			continue
+		}
+
+		isPersistent := len(previousRuns) == runs-1
+		for _, previousRun := range previousRuns {
+			previousCategory, ok := previousRun.Mismatches[id]
+			if !ok || previousCategory == validator.CategoryInFlight {
+				isPersistent = false
+				break
+			}
+		}
+		if isPersistent {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	persistent.Count = len(ids)
+	persistent.TransientCount = len(run.Mismatches) - len(ids)
+	persistent.IDs = ids[:utils.Min(50, len(ids))]
+
+	return
+}
+
+// previousValidations returns the final attempt of up to limit validations of the index before the one of run,
+// newest first, that compared the ids
+func (s *Store) previousValidations(run Run, limit int) (previousRuns []Run, err error) {
+	runs, err := s.List(run.Index, 0)
+	if err != nil {
+		return previousRuns, errors.Wrap(err, 0)
+	}
+
+	//the attempts of a validation are saved in order, so the newest run of each validation is its final attempt
+	seen := map[int64]bool{run.Invocation.UnixNano(): true}
+	for _, previousRun := range runs {
+		if !previousRun.Invocation.IsZero() {
+			if seen[previousRun.Invocation.UnixNano()] {
+				continue
+			}
+			seen[previousRun.Invocation.UnixNano()] = true
+		}
+
+		//runs saved before IDsChecked was added only have mismatches after comparing the ids
+		if !previousRun.IDsChecked && len(previousRun.Mismatches) == 0 {
+			continue
+		}
+		previousRuns = append(previousRuns, previousRun)
+		if len(previousRuns) == limit {
+			break
+		}
+	}
+
+	return previousRuns, nil
+}
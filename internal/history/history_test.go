@@ -0,0 +1,252 @@
+package history_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/history"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Validation history", func() {
+	var store *history.Store
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		path = filepath.Join(GinkgoT().TempDir(), "history.db")
+		store, err = history.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(store.Close()).To(Succeed())
+	})
+
+	It("should list the newest runs first", func() {
+		start := time.Now().UTC()
+		invalid := ValidationResponse{
+			Result: validation.ValidationInvalid,
+			Reason: "id mismatch",
+			Details: ResponseDetails{
+				IDs: validation.IdsDetails{InconsistencyAbsolute: 1},
+			},
+			Mismatches: CategorizedMismatches{"1234": CategoryMissingInIndex},
+		}
+		valid := ValidationResponse{Result: validation.ValidationValid}
+
+		_, err := store.Save(history.NewRun(invalid, "hosts.1", "hosts", start), 0)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.Save(history.NewRun(valid, "hosts.2", "hosts", start), 0)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.Save(history.NewRun(valid, "hosts.1", "hosts", start), 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		runs, err := store.List("", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runs).To(HaveLen(3))
+		Expect(runs[0].ID).To(Equal(uint64(3)))
+
+		runs, err = store.List("hosts.1", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runs).To(HaveLen(2))
+		Expect(runs[1].Result).To(Equal(validation.ValidationInvalid))
+		Expect(runs[1].Details.IDs.InconsistencyAbsolute).To(Equal(1))
+		Expect(runs[1].Mismatches).To(Equal(CategorizedMismatches{"1234": CategoryMissingInIndex}))
+		Expect(runs[1].StartedAt).To(BeTemporally("==", start))
+
+		runs, err = store.List("", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runs).To(HaveLen(1))
+	})
+
+	It("should only save the first mismatched ids", func() {
+		run := history.NewRun(ValidationResponse{
+			Result:     validation.ValidationInvalid,
+			Mismatches: CategorizedMismatches{"3": CategoryStale, "1": CategoryMissingInIndex, "2": CategoryStale},
+		}, "hosts.1", "hosts", time.Now())
+
+		_, err := store.Save(run, 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		runs, err := store.List("", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runs[0].Mismatches).To(Equal(CategorizedMismatches{"1": CategoryMissingInIndex, "2": CategoryStale}))
+		Expect(runs[0].MismatchCount).To(Equal(3))
+	})
+
+	It("should keep runs after the store is reopened", func() {
+		_, err := store.Save(history.NewRun(ValidationResponse{Result: validation.ValidationValid}, "hosts.1", "hosts", time.Now()), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Close()).To(Succeed())
+
+		store, err = history.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		runs, err := store.List("", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runs).To(HaveLen(1))
+	})
+
+	It("should delete the oldest runs beyond the retention", func() {
+		for _, index := range []string{"hosts.1", "hosts.2", "hosts.1", "hosts.2"} {
+			_, err := store.Save(history.NewRun(ValidationResponse{Result: validation.ValidationValid}, index, "hosts", time.Now()), 0)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		deleted, err := store.Prune(3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(1))
+
+		runs, err := store.List("", 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runs).To(HaveLen(3))
+		Expect(runs[2].ID).To(Equal(uint64(2)))
+
+		deleted, err = store.Prune(0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(0))
+	})
+
+	It("should report ids that mismatched in each of the last runs as persistent", func() {
+		mismatchedRun := func(mismatches CategorizedMismatches) history.Run {
+			return history.NewRun(ValidationResponse{
+				Result:     validation.ValidationInvalid,
+				Mismatches: mismatches,
+			}, "hosts.1", "hosts", time.Now())
+		}
+
+		for _, mismatches := range []CategorizedMismatches{
+			{"1": CategoryStale, "2": CategoryStale, "3": CategoryInFlight},
+			{"1": CategoryStale, "2": CategoryMissingInIndex, "3": CategoryInFlight},
+		} {
+			_, err := store.Save(mismatchedRun(mismatches), 0)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		current := mismatchedRun(CategorizedMismatches{
+			"1": CategoryStale,
+			"2": CategoryStale,
+			"3": CategoryInFlight,
+			"4": CategoryStale,
+		})
+
+		persistent, err := store.PersistentMismatches(current, 3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistent).To(Equal(PersistentMismatches{
+			Runs:           3,
+			IDs:            []string{"1", "2"},
+			Count:          2,
+			TransientCount: 2,
+		}))
+
+		persistent, err = store.PersistentMismatches(current, 4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistent.Count).To(Equal(0))
+	})
+
+	It("should only compare the final attempt of each earlier validation", func() {
+		first := time.Now().UTC().Add(-time.Hour)
+		second := first.Add(time.Minute)
+		for _, attempt := range []struct {
+			invocation time.Time
+			number     int
+			mismatches CategorizedMismatches
+		}{
+			{first, 0, CategorizedMismatches{"1": CategoryStale, "2": CategoryStale}},
+			{first, 1, CategorizedMismatches{"1": CategoryStale}},
+			{second, 0, CategorizedMismatches{"1": CategoryStale, "2": CategoryStale}},
+			{second, 1, CategorizedMismatches{"1": CategoryStale}},
+		} {
+			run := history.NewRun(ValidationResponse{
+				Result:     validation.ValidationInvalid,
+				Mismatches: attempt.mismatches,
+			}, "hosts.1", "hosts", attempt.invocation)
+			run.Attempt = attempt.number
+			_, err := store.Save(run, 0)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		current := history.NewRun(ValidationResponse{
+			Result:     validation.ValidationInvalid,
+			Mismatches: CategorizedMismatches{"1": CategoryStale, "2": CategoryStale},
+		}, "hosts.1", "hosts", time.Now())
+
+		persistent, err := store.PersistentMismatches(current, 3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistent.IDs).To(Equal([]string{"1"}))
+	})
+
+	It("should not compare the attempts of the same validation", func() {
+		invocation := time.Now()
+		previous := history.NewRun(ValidationResponse{
+			Result:     validation.ValidationInvalid,
+			Mismatches: CategorizedMismatches{"1": CategoryStale},
+		}, "hosts.1", "hosts", invocation)
+		_, err := store.Save(previous, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		current := history.NewRun(ValidationResponse{
+			Result:     validation.ValidationInvalid,
+			Mismatches: CategorizedMismatches{"1": CategoryStale},
+		}, "hosts.1", "hosts", invocation.Add(time.Minute))
+		current.Invocation = invocation
+		current.Attempt = 1
+
+		persistent, err := store.PersistentMismatches(current, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistent.Count).To(Equal(0))
+	})
+
+	It("should skip validations that stopped before comparing the ids", func() {
+		for _, response := range []ValidationResponse{
+			{Result: validation.ValidationInvalid, Mismatches: CategorizedMismatches{"1": CategoryStale}},
+			{Result: validation.ValidationInvalid, Reason: "count mismatch"},
+		} {
+			_, err := store.Save(history.NewRun(response, "hosts.1", "hosts", time.Now()), 0)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		current := history.NewRun(ValidationResponse{
+			Result:     validation.ValidationInvalid,
+			Mismatches: CategorizedMismatches{"1": CategoryStale},
+		}, "hosts.1", "hosts", time.Now())
+
+		persistent, err := store.PersistentMismatches(current, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistent.IDs).To(Equal([]string{"1"}))
+	})
+
+	It("should compare valid validations without mismatches", func() {
+		for _, response := range []ValidationResponse{
+			{Result: validation.ValidationInvalid, Mismatches: CategorizedMismatches{"1": CategoryStale}},
+			{Result: validation.ValidationValid, Mismatches: CategorizedMismatches{}},
+		} {
+			_, err := store.Save(history.NewRun(response, "hosts.1", "hosts", time.Now()), 0)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		current := history.NewRun(ValidationResponse{
+			Result:     validation.ValidationInvalid,
+			Mismatches: CategorizedMismatches{"1": CategoryStale},
+		}, "hosts.1", "hosts", time.Now())
+
+		persistent, err := store.PersistentMismatches(current, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(persistent.Count).To(Equal(0))
+	})
+
+	It("should save and load incremental validation checkpoints", func() {
+		checkpoint, err := store.LoadCheckpoint("hosts.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint).To(Equal(Checkpoint{}))
+
+		Expect(store.SaveCheckpoint(Checkpoint{Key: "hosts.1", LastID: "1234", RecordsValidated: 10})).To(Succeed())
+		checkpoint, err = store.LoadCheckpoint("hosts.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.LastID).To(Equal("1234"))
+		Expect(checkpoint.RecordsValidated).To(Equal(10))
+	})
+})
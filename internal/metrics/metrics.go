@@ -20,6 +20,16 @@ var (
 		Name: "xjoin_core_lag",
 		Help: "The number of milliseconds between xjoin-core reading from the source topic and writing to the sink topic.",
 	})
+
+	persistentMismatches = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xjoin_validation_persistent_mismatches",
+		Help: "The number of records that mismatched in each of the last PERSISTENT_MISMATCH_RUNS validation runs.",
+	})
+
+	transientMismatches = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "xjoin_validation_transient_mismatches",
+		Help: "The number of mismatched records that are not persistent mismatches.",
+	})
 )
 
 func ObserveTotalRecordLag(lag float64) {
@@ -34,10 +44,17 @@ func ObserveCoreLag(lag float64) {
 	coreLag.Set(lag)
 }
 
+func ObserveMismatches(persistent int, transient int) {
+	persistentMismatches.Set(float64(persistent))
+	transientMismatches.Set(float64(transient))
+}
+
 func Push(url string, job string) error {
 	return push.New(url, job).
 		Collector(totalRecordLag).
 		Collector(debeziumLag).
 		Collector(coreLag).
+		Collector(persistentMismatches).
+		Collector(transientMismatches).
 		Push()
 }
@@ -0,0 +1,33 @@
+package opensearch
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/go-errors/errors"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+func (o *OSClient) ResolveIndices() (indices []string, err error) {
+	req := opensearchapi.IndicesGetAliasRequest{
+		Index: []string{o.index},
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return indices, errors.Wrap(err, 0)
+	}
+
+	byteValue, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode >= 300 {
+		return indices, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when resolving index aliases. StatusCode: %v, Body: %s",
+			res.StatusCode, byteValue)), 0)
+	}
+
+	return elasticsearch.ParseAliasesResponse(byteValue)
+}
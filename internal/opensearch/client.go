@@ -0,0 +1,57 @@
+package opensearch
+
+import (
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
+	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
+	"github.com/go-errors/errors"
+	"github.com/opensearch-project/opensearch-go/v2"
+	"net/http"
+)
+
+// OSClient sends the requests built by elasticsearch.Queries with the OpenSearch client
+type OSClient struct {
+	*elasticsearch.Queries
+	client *opensearch.Client
+	index  string
+	log    logger.Log
+}
+
+type OSParams struct {
+	Url              string
+	Transport        http.RoundTripper //e.g. elasticsearch.NewTransport for TLS and credentials
+	Index            string
+	RootNode         string
+	HashField        string //dotted path of a stored content hash
+	Filter           filter.Filter
+	ParsedAvroSchema avro.ParsedAvroSchema
+	Log              logger.Log
+}
+
+func NewOSClient(params OSParams) (*OSClient, error) {
+	cfg := opensearch.Config{
+		Addresses: []string{params.Url},
+		Transport: params.Transport,
+	}
+
+	client, err := opensearch.NewClient(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	osClient := OSClient{
+		client: client,
+		index:  params.Index,
+		log:    params.Log,
+	}
+	osClient.Queries = elasticsearch.NewQueries(&osClient, elasticsearch.QueryParams{
+		RootNode:         params.RootNode,
+		HashField:        params.HashField,
+		Filter:           params.Filter,
+		ParsedAvroSchema: params.ParsedAvroSchema,
+		Log:              params.Log,
+	})
+
+	return &osClient, nil
+}
@@ -0,0 +1,47 @@
+package opensearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-errors/errors"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+	"io/ioutil"
+)
+
+func (o *OSClient) GetDocumentsByIDs(ids []string) (records []map[string]interface{}, err error) {
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+
+	reqJSON, err := json.Marshal(map[string]interface{}{"query": o.DocumentsQuery(ids)})
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+	requestSize := len(ids)
+
+	searchReq := opensearchapi.SearchRequest{
+		Index: []string{o.index},
+		Size:  &requestSize,
+		Sort:  []string{"_id"},
+		Body:  bytes.NewReader(reqJSON),
+	}
+
+	searchRes, err := searchReq.Do(ctx, o.client)
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+	byteValue, _ := ioutil.ReadAll(searchRes.Body)
+	if searchRes.StatusCode >= 400 {
+		return nil, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when getting opensearch records by id. StatusCode: %v, Body: %s",
+			searchRes.StatusCode, byteValue)), 0)
+	}
+
+	records, err = o.ParseDocuments(byteValue)
+	if err != nil {
+		return records, errors.Wrap(err, 0)
+	}
+
+	return
+}
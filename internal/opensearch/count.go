@@ -0,0 +1,50 @@
+package opensearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	. "github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/go-errors/errors"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+	"io/ioutil"
+)
+
+func (o *OSClient) CountIndex() (count int, err error) {
+	req := opensearchapi.CountRequest{
+		Index: []string{o.index},
+	}
+	if query := o.CountQuery(); query != nil {
+		reqJSON, err := json.Marshal(map[string]interface{}{"query": query})
+		if err != nil {
+			return count, errors.Wrap(err, 0)
+		}
+		req.Body = bytes.NewReader(reqJSON)
+	}
+
+	o.log.Debug("OpenSearch count request", "request", req)
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	res, err := req.Do(ctx, o.client)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	if res.StatusCode >= 300 {
+		return count, errors.Wrap(fmt.Errorf(
+			"invalid response code when counting index: %v", res.StatusCode), 0)
+	}
+
+	var countIDsResponse CountIDsResponse
+	byteValue, _ := ioutil.ReadAll(res.Body)
+	err = json.Unmarshal(byteValue, &countIDsResponse)
+	if err != nil {
+		return count, errors.Wrap(err, 0)
+	}
+
+	o.log.Debug("OpenSearch count response", "response", res, "body", countIDsResponse)
+
+	return countIDsResponse.Count, nil
+}
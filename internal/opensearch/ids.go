@@ -0,0 +1,125 @@
+package opensearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	. "github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/go-errors/errors"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+	"io/ioutil"
+	"time"
+)
+
+// Search runs a search request in the index
+func (o *OSClient) Search(body map[string]interface{}) ([]byte, error) {
+	reqJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	searchReq := opensearchapi.SearchRequest{
+		Index: []string{o.index},
+		Body:  bytes.NewReader(reqJSON),
+	}
+	searchRes, err := searchReq.Do(ctx, o.client)
+	if err != nil {
+		return nil, errors.Wrap(err, 0)
+	}
+	byteValue, _ := ioutil.ReadAll(searchRes.Body)
+	if searchRes.StatusCode >= 400 {
+		return nil, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when searching opensearch. StatusCode: %v, Body: %s",
+			searchRes.StatusCode, byteValue)), 0)
+	}
+
+	return byteValue, nil
+}
+
+// SearchIDs scrolls through the ids of the documents matched by reqJSON
+func (o *OSClient) SearchIDs(reqJSON []byte) (responseIds []string, err error) {
+	size := new(int)
+	*size = 5000
+
+	searchReq := opensearchapi.SearchRequest{
+		Index:  []string{o.index},
+		Scroll: time.Duration(1) * time.Minute,
+		Body:   bytes.NewReader(reqJSON),
+		Source: []string{o.IDField()},
+		Size:   size,
+		Sort:   []string{"_doc"},
+	}
+
+	ctx, cancel := utils.DefaultContext()
+	defer cancel()
+	searchRes, err := searchReq.Do(ctx, o.client)
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+
+	if searchRes.StatusCode >= 400 {
+		bodyBytes, _ := ioutil.ReadAll(searchRes.Body)
+
+		return responseIds, errors.Wrap(errors.New(fmt.Sprintf(
+			"invalid response code when getting records ids. StatusCode: %v, Body: %s",
+			searchRes.StatusCode, bodyBytes)), 0)
+	}
+
+	ids, searchJSON, err := parseSearchIdsResponse(searchRes)
+	if err != nil {
+		return responseIds, errors.Wrap(err, 0)
+	}
+
+	if searchJSON.Hits.Total.Value == 0 {
+		return ids, nil
+	}
+
+	moreHits := true
+	scrollID := searchJSON.ScrollID
+
+	for moreHits {
+		scrollReq := opensearchapi.ScrollRequest{
+			Scroll:   time.Duration(1) * time.Minute,
+			ScrollID: scrollID,
+		}
+
+		ctx, cancel := utils.DefaultContext()
+		defer cancel()
+		scrollRes, err := scrollReq.Do(ctx, o.client)
+		if err != nil {
+			return responseIds, errors.Wrap(err, 0)
+		}
+
+		moreIds, scrollJSON, err := parseSearchIdsResponse(scrollRes)
+		if err != nil {
+			return responseIds, errors.Wrap(err, 0)
+		}
+		ids = append(ids, moreIds...)
+		scrollID = scrollJSON.ScrollID
+
+		if len(scrollJSON.Hits.Hits) == 0 {
+			moreHits = false
+		}
+	}
+
+	return ids, nil
+}
+
+func parseSearchIdsResponse(scrollRes *opensearchapi.Response) ([]string, SearchIDsResponse, error) {
+	var ids []string
+	var searchJSON SearchIDsResponse
+	byteValue, _ := ioutil.ReadAll(scrollRes.Body)
+	err := json.Unmarshal(byteValue, &searchJSON)
+	if err != nil {
+		return nil, searchJSON, errors.Wrap(err, 0)
+	}
+
+	for _, hit := range searchJSON.Hits.Hits {
+		ids = append(ids, hit.ID)
+	}
+
+	return ids, searchJSON, nil
+}
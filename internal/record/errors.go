@@ -0,0 +1,72 @@
+package record
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"strings"
+)
+
+// errUnexpectedType is the reason of a FieldParseError when the Go type of the value cannot represent the field
+var errUnexpectedType = stdErrors.New("unexpected type")
+
+// FieldParseError is a value of a record that cannot be converted to the type of its field
+type FieldParseError struct {
+	ID           string `json:"id"`
+	Field        string `json:"field"`
+	ExpectedType string `json:"expectedType"`
+	ActualType   string `json:"actualType"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+func (e FieldParseError) Error() string {
+	message := fmt.Sprintf("unable to parse field %s of record %s as %s from %s", e.Field, e.ID, e.ExpectedType, e.ActualType)
+	if e.Reason != "" {
+		message = message + ": " + e.Reason
+	}
+	return message
+}
+
+func newFieldParseError(id string, field string, expectedType string, value interface{}, reason error) FieldParseError {
+	fieldError := FieldParseError{
+		ID:           id,
+		Field:        field,
+		ExpectedType: expectedType,
+		ActualType:   fmt.Sprintf("%T", value),
+	}
+	if reason != nil {
+		fieldError.Reason = reason.Error()
+	}
+	return fieldError
+}
+
+// ParseErrors are the records of a response that could not be parsed. The other records are still returned
+// with ParseErrors, so a single invalid record does not abort the validation.
+type ParseErrors []FieldParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// IDs returns the id of each record that could not be parsed
+func (e ParseErrors) IDs() (ids []string) {
+	for _, fieldError := range e {
+		ids = append(ids, fieldError.ID)
+	}
+	return
+}
+
+// AsFieldParseError returns the FieldParseError in the chain of err
+func AsFieldParseError(err error) (fieldError FieldParseError, ok bool) {
+	ok = stdErrors.As(err, &fieldError)
+	return
+}
+
+// AsParseErrors returns the ParseErrors in the chain of err
+func AsParseErrors(err error) (parseErrors ParseErrors, ok bool) {
+	ok = stdErrors.As(err, &parseErrors)
+	return
+}
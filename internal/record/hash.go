@@ -0,0 +1,25 @@
+package record
+
+import "strconv"
+
+// BucketHash is the number of records in a range of ids and the sum of the HashValue of each record modulo 2^64.
+// The sum does not depend on the order of the records so each store can compute it with an aggregation.
+type BucketHash struct {
+	Count int
+	Hash  uint64
+}
+
+// Add adds the hash of a record to the bucket
+func (b *BucketHash) Add(hash string) {
+	b.Count++
+	b.Hash += HashValue(hash)
+}
+
+// HashValue is the unsigned integer of the first 16 hex characters of a hash
+func HashValue(hash string) uint64 {
+	if len(hash) > 16 {
+		hash = hash[:16]
+	}
+	value, _ := strconv.ParseUint(hash, 16, 64)
+	return value
+}
@@ -0,0 +1,110 @@
+package record_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	. "github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	"github.com/go-test/deep"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const nestedDocument = `{"owner": {
+	"id": "1",
+	"nickname": null,
+	"address": {"city": "Brno", "zip": 60200},
+	"previous_addresses": [{"city": "Praha", "zip": 11000}, {"city": "Ostrava", "zip": 70200}],
+	"limits": {"cpu": 4, "memory": 8192},
+	"modified_on": "2023-01-02T03:04:05Z"
+}}`
+
+var _ = Describe("Nested schemas", func() {
+	var parsedSchema avro.ParsedAvroSchema
+
+	BeforeEach(func() {
+		schemaParser := avro.SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/nested")}
+		var err error
+		parsedSchema, err = schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	scanRow := func(values ...driver.Value) map[string]interface{} {
+		mockDB, dbMock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		dbMock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(parsedSchema.DatabaseColumns).AddRow(values...))
+
+		rows, err := sqlx.NewDb(mockDB, "sqlmock").Queryx("SELECT")
+		Expect(err).ToNot(HaveOccurred())
+		defer rows.Close()
+		Expect(rows.Next()).To(BeTrue())
+
+		row := make(map[string]interface{})
+		Expect(rows.MapScan(row)).To(Succeed())
+		return map[string]interface{}{"owner": row}
+	}
+
+	parse := func(record map[string]interface{}) (map[string]interface{}, error) {
+		recordParser := RecordParser{Record: record, ParsedAvroSchema: parsedSchema}
+		return recordParser.Parse()
+	}
+
+	It("should resolve unions in any order and named types", func() {
+		Expect(parsedSchema.RootNode).To(Equal("owner"))
+		Expect(parsedSchema.DatabaseColumns).To(Equal(
+			[]string{"id", "nickname", "address", "previous_addresses", "limits", "modified_on"}))
+
+		nickname := parsedSchema.Fields[1].Type
+		Expect(nickname.Type).To(Equal("string"))
+		Expect(nickname.Nullable).To(BeTrue())
+
+		previousAddresses := parsedSchema.Fields[3].Type
+		Expect(previousAddresses.Items.Name).To(Equal("Address"))
+		Expect(previousAddresses.Items.Fields).To(HaveLen(2))
+
+		Expect(parsedSchema.Fields[4].Type.Values.XJoinType).To(Equal("long"))
+	})
+
+	It("should parse nested records, arrays of records and maps from JSON columns", func() {
+		dbRecord, err := parse(scanRow(
+			"1",
+			nil,
+			[]byte(`{"city": "Brno", "zip": 60200, "country": "CZ"}`),
+			[]byte(`[{"city": "Praha", "zip": 11000}, {"city": "Ostrava", "zip": 70200}]`),
+			[]byte(`{"cpu": 4, "memory": 8192}`),
+			"2023-01-02T03:04:05Z"))
+		Expect(err).ToNot(HaveOccurred())
+
+		var source map[string]interface{}
+		Expect(json.Unmarshal([]byte(nestedDocument), &source)).To(Succeed())
+		esRecord, err := parse(source)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(deep.Equal(dbRecord, esRecord)).To(BeEmpty())
+
+		owner := dbRecord["owner"].(map[string]interface{})
+		Expect(owner["address"]).To(Equal(map[string]interface{}{"city": "Brno", "zip": int64(60200)}))
+		Expect(owner["limits"]).To(Equal(map[string]interface{}{"cpu": int64(4), "memory": int64(8192)}))
+	})
+
+	It("should report the path of a nested value that cannot be parsed", func() {
+		_, err := parse(scanRow(
+			"1",
+			nil,
+			nil,
+			[]byte(`[{"city": "Praha", "zip": "unknown"}]`),
+			nil,
+			"2023-01-02T03:04:05Z"))
+
+		fieldError, ok := AsFieldParseError(err)
+		Expect(ok).To(BeTrue())
+		Expect(fieldError.ID).To(Equal("1"))
+		Expect(fieldError.Field).To(Equal("previous_addresses[0].zip"))
+		Expect(fieldError.ExpectedType).To(Equal("integer"))
+		Expect(fieldError.ActualType).To(Equal("string"))
+	})
+})
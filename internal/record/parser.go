@@ -1,13 +1,11 @@
 package record
 
 import (
-	"encoding/json"
 	"github.com/RedHatInsights/xjoin-validation/internal/avro"
 	"github.com/RedHatInsights/xjoin-validation/internal/common"
 	"github.com/RedHatInsights/xjoin-validation/internal/metrics"
 	"github.com/go-errors/errors"
 	"golang.org/x/exp/slices"
-	"time"
 )
 
 type RecordParser struct {
@@ -15,120 +13,64 @@ type RecordParser struct {
 	ParsedAvroSchema avro.ParsedAvroSchema
 }
 
+// Parse normalizes the fields of the record. A value that cannot be converted to the type of its field is
+// returned as a FieldParseError instead of panicking, so the caller can report the record and continue.
 func (r *RecordParser) Parse() (parsedRecord map[string]interface{}, err error) {
 	parsedRecord = make(map[string]interface{})
-	record := r.Record[r.ParsedAvroSchema.RootNode].(map[string]interface{})
-
-	dbzRead := float64(-1)
-	dbzWrite := float64(-1)
-	coreRead := float64(-1)
-	coreWrite := float64(-1)
-	esWrite := float64(-1)
+	rootNode := r.ParsedAvroSchema.RootNode
+	record, ok := r.Record[rootNode].(map[string]interface{})
+	if !ok {
+		return parsedRecord, errors.Wrap(newFieldParseError("", rootNode, "record", r.Record[rootNode], errUnexpectedType), 0)
+	}
+	id := recordID(record)
 
-	if r.Record["__core_read_ms"] != nil {
-		coreRead = r.Record["__core_read_ms"].(float64)
+	coreRead, err := lagMs(id, "__core_read_ms", r.Record["__core_read_ms"])
+	if err != nil {
+		return parsedRecord, errors.Wrap(err, 0)
 	}
 
-	if r.Record["__core_write_ms"] != nil {
-		coreWrite = r.Record["__core_write_ms"].(float64)
+	coreWrite, err := lagMs(id, "__core_write_ms", r.Record["__core_write_ms"])
+	if err != nil {
+		return parsedRecord, errors.Wrap(err, 0)
 	}
 
-	if r.Record["__es_write_ms"] != nil {
-		esWrite = r.Record["__es_write_ms"].(float64)
+	esWrite, err := lagMs(id, "__es_write_ms", r.Record["__es_write_ms"])
+	if err != nil {
+		return parsedRecord, errors.Wrap(err, 0)
 	}
 
-	for _, field := range r.ParsedAvroSchema.FullAvroSchema.Fields[0].Type[0].Fields {
-		if slices.Contains(r.ParsedAvroSchema.TransformedFields, r.ParsedAvroSchema.RootNode+"."+field.Name) {
+	dbzRead := float64(-1)
+	dbzWrite := float64(-1)
+
+	for _, field := range r.ParsedAvroSchema.Fields {
+		if slices.Contains(r.ParsedAvroSchema.TransformedFields, rootNode+"."+field.Name) {
 			continue //TODO: validate transformed fields
 		}
 
 		switch field.Name {
 		case "__dbz_source_ts_ms":
-			if record[field.Name] != nil {
-				dbzRead = record[field.Name].(float64)
-			}
+			dbzRead, err = lagMs(id, field.Name, record[field.Name])
 		case "__dbz_ts_ms":
-			if record[field.Name] != nil {
-				dbzWrite = record[field.Name].(float64)
-			}
+			dbzWrite, err = lagMs(id, field.Name, record[field.Name])
+		}
+		if err != nil {
+			return parsedRecord, errors.Wrap(err, 0)
 		}
 
 		if slices.Contains(common.InternalFields, field.Name) {
 			continue
 		}
 
-		var xjoinType string
-		if len(field.Type) == 1 {
-			xjoinType = field.Type[0].XJoinType
-		} else {
-			xjoinType = field.Type[1].XJoinType
-		}
-
-		switch xjoinType {
-		case "string":
-			if record[field.Name] == nil {
-				parsedRecord[field.Name] = ""
-			} else {
-				switch record[field.Name].(type) {
-				case string:
-					parsedRecord[field.Name] = record[field.Name]
-				case []uint8:
-					parsedRecord[field.Name] = string(record[field.Name].([]uint8)[:])
-				case interface{}:
-					if record[field.Name] == nil {
-						parsedRecord[field.Name] = ""
-					} else {
-						parsedRecord[field.Name] = record[field.Name].(string)
-					}
-				}
-			}
-		case "date_nanos":
-			switch record[field.Name].(type) {
-			case time.Time:
-				parsedRecord[field.Name] = record[field.Name].(time.Time)
-			case string:
-				parsedField, err := time.Parse(time.RFC3339Nano, record[field.Name].(string))
-				if err != nil {
-					return parsedRecord, errors.Wrap(err, 0)
-				}
-				parsedRecord[field.Name] = parsedField
-			}
-		case "json":
-			//convert json fields to map[string]interface{}
-			var parsedField map[string]interface{}
-
-			if record[field.Name] == nil {
-				parsedRecord[field.Name] = parsedField
-			} else {
-				switch record[field.Name].(type) {
-				case string:
-					err := json.Unmarshal([]byte(record[field.Name].(string)), &parsedField)
-					if err != nil {
-						return parsedRecord, errors.Wrap(err, 0)
-					}
-					parsedRecord[field.Name] = parsedField
-				case []uint8:
-					err := json.Unmarshal(record[field.Name].([]uint8)[:], &parsedField)
-					if err != nil {
-						return parsedRecord, errors.Wrap(err, 0)
-					}
-					parsedRecord[field.Name] = parsedField
-				case map[string]interface{}:
-					parsedRecord[field.Name] = record[field.Name]
-				}
-			}
-		case "boolean":
-			parsedRecord[field.Name] = record[field.Name].(bool)
-		case "byte":
-			parsedRecord[field.Name] = record[field.Name].(byte)
-		case "array":
-			parsedRecord[field.Name] = record[field.Name].(string)
-		default:
-			parsedRecord[field.Name] = record[field.Name]
+		parsedRecord[field.Name], err = parseValue(field.Type, record[field.Name], field.Name)
+		if fieldError, ok := AsFieldParseError(err); ok {
+			fieldError.ID = id
+			return parsedRecord, errors.Wrap(fieldError, 0)
+		} else if err != nil {
+			return parsedRecord, errors.Wrap(err, 0)
 		}
 	}
 
-	parsedRecord = map[string]interface{}{r.ParsedAvroSchema.RootNode: parsedRecord}
+	parsedRecord = map[string]interface{}{rootNode: parsedRecord}
 
 	if esWrite != -1 && dbzRead != -1 {
 		metrics.ObserveTotalRecordLag(esWrite - dbzRead)
@@ -144,3 +86,26 @@ func (r *RecordParser) Parse() (parsedRecord map[string]interface{}, err error)
 
 	return
 }
+
+// recordID returns the id of the record for the errors, or an empty string when the id is not a string
+func recordID(record map[string]interface{}) string {
+	id, err := parseString(record["id"]) //TODO: parse ID field from schema
+	if err != nil {
+		return ""
+	}
+	return id.(string)
+}
+
+// lagMs returns the epoch milliseconds of a lag metric field, or -1 when the field is not set
+func lagMs(id string, field string, value interface{}) (float64, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return -1, nil
+	case float64:
+		return typedValue, nil
+	case int64:
+		return float64(typedValue), nil
+	default:
+		return -1, newFieldParseError(id, field, "float", value, errUnexpectedType)
+	}
+}
@@ -0,0 +1,217 @@
+package record_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	. "github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	"github.com/go-test/deep"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var columns = []string{"id", "small", "count", "big", "price", "ratio", "state", "address", "network", "born_on",
+	"seen_at", "modified_on", "enabled", "payload", "labels", "scores", "attributes"}
+
+const document = `{"thing": {
+	"id": "0a7e3b1c-5c2f-4a8e-9d1b-6f0e2c3d4a5b",
+	"small": 3,
+	"count": 42,
+	"big": 1234567890123,
+	"price": 12.5,
+	"ratio": 0.1,
+	"state": "active",
+	"address": "10.0.0.1",
+	"network": "10.1.0.0/16",
+	"born_on": "2023-01-02",
+	"seen_at": "2023-01-02T03:04:05.000006",
+	"modified_on": "2023-01-02T08:04:05.000006Z",
+	"enabled": true,
+	"payload": "AAEC",
+	"labels": ["a", "b c", null],
+	"scores": [1, 2, 3],
+	"attributes": {"list": [[1, 2], ["x"]], "nested": {"a": null}}
+}}`
+
+var _ = Describe("RecordParser", func() {
+	var parsedSchema avro.ParsedAvroSchema
+	var dbMock sqlmock.Sqlmock
+	var db *sqlx.DB
+
+	BeforeEach(func() {
+		schemaParser := avro.SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/types")}
+		var err error
+		parsedSchema, err = schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+
+		mockDB, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		dbMock = mock
+		db = sqlx.NewDb(mockDB, "sqlmock")
+	})
+
+	//scanRow returns the row as MapScan reads it, with the driver values lib/pq returns for each column type
+	scanRow := func(values ...interface{}) map[string]interface{} {
+		driverValues := make([]driver.Value, len(values))
+		for i, value := range values {
+			driverValues[i] = value
+		}
+		rows := sqlmock.NewRows(columns).AddRow(driverValues...)
+		dbMock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+		result, err := db.Queryx("SELECT")
+		Expect(err).ToNot(HaveOccurred())
+		defer result.Close()
+		Expect(result.Next()).To(BeTrue())
+
+		row := make(map[string]interface{})
+		Expect(result.MapScan(row)).To(Succeed())
+		return map[string]interface{}{"thing": row}
+	}
+
+	parse := func(record map[string]interface{}) (map[string]interface{}, error) {
+		recordParser := RecordParser{Record: record, ParsedAvroSchema: parsedSchema}
+		return recordParser.Parse()
+	}
+
+	row := func() []interface{} {
+		return []interface{}{
+			[]byte("0a7e3b1c-5c2f-4a8e-9d1b-6f0e2c3d4a5b"), //uuid
+			int64(3),              //smallint
+			int64(42),             //integer
+			int64(1234567890123),  //bigint
+			[]byte("12.50"),       //numeric(10,2)
+			float64(float32(0.1)), //real
+			[]byte("active"),      //enum
+			[]byte("10.0.0.1"),    //inet
+			[]byte("10.1.0.0/16"), //cidr
+			time.Date(2023, 1, 2, 0, 0, 0, 0, time.FixedZone("", 0)),          //date
+			time.Date(2023, 1, 2, 3, 4, 5, 6000, time.FixedZone("", 0)),       //timestamp
+			time.Date(2023, 1, 2, 3, 4, 5, 6000, time.FixedZone("", -5*3600)), //timestamptz
+			true,                     //boolean
+			[]byte{0, 1, 2},          //bytea
+			[]byte(`{a,"b c",NULL}`), //text[]
+			[]byte(`{1,2,3}`),        //integer[]
+			[]byte(`{"list": [[1, 2], ["x"]], "nested": {"a": null}}`), //jsonb
+		}
+	}
+
+	It("should parse a database row and an index document to the same record", func() {
+		dbRecord, err := parse(scanRow(row()...))
+		Expect(err).ToNot(HaveOccurred())
+
+		var source map[string]interface{}
+		Expect(json.Unmarshal([]byte(document), &source)).To(Succeed())
+		esRecord, err := parse(source)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(deep.Equal(dbRecord, esRecord)).To(BeEmpty())
+	})
+
+	It("should map each database type to the Go type of its xjoin type", func() {
+		record, err := parse(scanRow(row()...))
+		Expect(err).ToNot(HaveOccurred())
+
+		thing := record["thing"].(map[string]interface{})
+		Expect(thing["id"]).To(Equal("0a7e3b1c-5c2f-4a8e-9d1b-6f0e2c3d4a5b"))
+		Expect(thing["small"]).To(Equal(int64(3)))
+		Expect(thing["big"]).To(Equal(int64(1234567890123)))
+		Expect(thing["price"]).To(Equal(12.5))
+		Expect(thing["ratio"]).To(Equal(float64(float32(0.1))))
+		Expect(thing["state"]).To(Equal("active"))
+		Expect(thing["network"]).To(Equal("10.1.0.0/16"))
+		Expect(thing["born_on"]).To(Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)))
+		Expect(thing["modified_on"]).To(Equal(time.Date(2023, 1, 2, 8, 4, 5, 6000, time.UTC)))
+		Expect(thing["payload"]).To(Equal("AAEC"))
+		Expect(thing["labels"]).To(Equal([]interface{}{"a", "b c", ""}))
+		Expect(thing["scores"]).To(Equal([]interface{}{int64(1), int64(2), int64(3)}))
+		Expect(thing["attributes"].(map[string]interface{})["list"]).To(Equal(
+			[]interface{}{[]interface{}{float64(1), float64(2)}, []interface{}{"x"}}))
+	})
+
+	It("should parse NULL values", func() {
+		values := row()
+		values[1] = nil  //smallint
+		values[7] = nil  //inet
+		values[13] = nil //bytea
+		values[14] = nil //text[]
+		values[16] = nil //jsonb
+
+		record, err := parse(scanRow(values...))
+		Expect(err).ToNot(HaveOccurred())
+
+		thing := record["thing"].(map[string]interface{})
+		Expect(thing["small"]).To(BeNil())
+		Expect(thing["address"]).To(BeNil())
+		Expect(thing["payload"]).To(BeNil())
+		Expect(thing["labels"]).To(Equal([]interface{}{}))
+		Expect(thing["attributes"]).To(BeNil())
+	})
+
+	It("should normalize a host prefix to its address", func() {
+		values := row()
+		values[7] = []byte("10.0.0.1/32")
+
+		record, err := parse(scanRow(values...))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record["thing"].(map[string]interface{})["address"]).To(Equal("10.0.0.1"))
+	})
+
+	It("should return an error for an integer out of range of its width", func() {
+		values := row()
+		values[1] = int64(40000)
+
+		_, err := parse(scanRow(values...))
+		fieldError, ok := AsFieldParseError(err)
+		Expect(ok).To(BeTrue())
+		Expect(fieldError).To(Equal(FieldParseError{
+			ID:           "0a7e3b1c-5c2f-4a8e-9d1b-6f0e2c3d4a5b",
+			Field:        "small",
+			ExpectedType: "short",
+			ActualType:   "int64",
+			Reason:       "40000 is out of range of a 16 bit integer",
+		}))
+	})
+
+	It("should return a FieldParseError instead of panicking on an unexpected type", func() {
+		var source map[string]interface{}
+		Expect(json.Unmarshal([]byte(document), &source)).To(Succeed())
+		source["thing"].(map[string]interface{})["enabled"] = "maybe"
+
+		_, err := parse(source)
+		fieldError, ok := AsFieldParseError(err)
+		Expect(ok).To(BeTrue())
+		Expect(fieldError.Field).To(Equal("enabled"))
+		Expect(fieldError.ExpectedType).To(Equal("boolean"))
+		Expect(fieldError.ActualType).To(Equal("string"))
+
+		source["thing"].(map[string]interface{})["enabled"] = []interface{}{true}
+		_, err = parse(source)
+		fieldError, ok = AsFieldParseError(err)
+		Expect(ok).To(BeTrue())
+		Expect(fieldError.ActualType).To(Equal("[]interface {}"))
+		Expect(fieldError.Reason).To(Equal("unexpected type"))
+	})
+
+	It("should return a FieldParseError when the root node or a lag field has the wrong type", func() {
+		_, err := parse(map[string]interface{}{"thing": "not a record"})
+		fieldError, ok := AsFieldParseError(err)
+		Expect(ok).To(BeTrue())
+		Expect(fieldError.Field).To(Equal("thing"))
+		Expect(fieldError.ExpectedType).To(Equal("record"))
+
+		var source map[string]interface{}
+		Expect(json.Unmarshal([]byte(document), &source)).To(Succeed())
+		source["__core_read_ms"] = "yesterday"
+		_, err = parse(source)
+		fieldError, ok = AsFieldParseError(err)
+		Expect(ok).To(BeTrue())
+		Expect(fieldError.Field).To(Equal("__core_read_ms"))
+		Expect(fieldError.ID).To(Equal("0a7e3b1c-5c2f-4a8e-9d1b-6f0e2c3d4a5b"))
+	})
+})
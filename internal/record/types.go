@@ -0,0 +1,446 @@
+package record
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/lib/pq"
+)
+
+// timestampLayouts are the layouts of the timestamps read as strings, from the index or from a database driver
+// that does not parse timestamps. A timestamp without a zone is UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// XJoinType returns the xjoin.type of an avro type, or the xjoin type of the avro type when the xjoin.type is not set
+func XJoinType(avroType avro.Type) string {
+	if avroType.XJoinType != "" {
+		return avroType.XJoinType
+	}
+
+	switch avroType.Type {
+	case "int":
+		return "integer"
+	case "enum":
+		return "string"
+	case "bytes", "fixed":
+		return "binary"
+	default:
+		return avroType.Type
+	}
+}
+
+// parseValue normalizes a value read from the database or the index to the Go type of its xjoin type, so the
+// values from both sides are deep equal when they represent the same data. Records, maps and arrays are parsed
+// recursively. path is the dotted path of the value in the record, e.g. owner.addresses[0].city
+func parseValue(valueType avro.Type, value interface{}, path string) (interface{}, error) {
+	switch valueType.Type {
+	case "record":
+		return parseRecord(valueType, value, path)
+	case "map":
+		return parseMap(valueType, value, path)
+	case "array":
+		return parseArray(valueType, value, path)
+	case "union":
+		//the schema parser rejects these unions
+		return nil, newFieldParseError("", path, "union", value, fmt.Errorf("unsupported union of more than one type"))
+	}
+
+	var parsed interface{}
+	var err error
+	switch XJoinType(valueType) {
+	case "string":
+		parsed, err = parseString(value)
+	case "date_nanos":
+		parsed, err = parseTimestamp(value)
+	case "date":
+		parsed, err = parseDate(value)
+	case "json":
+		parsed, err = parseJSON(value)
+	case "boolean":
+		parsed, err = parseBoolean(value)
+	case "byte":
+		parsed, err = parseInteger(value, 8)
+	case "short":
+		parsed, err = parseInteger(value, 16)
+	case "integer":
+		parsed, err = parseInteger(value, 32)
+	case "long":
+		parsed, err = parseInteger(value, 64)
+	case "float", "half_float":
+		parsed, err = parseFloat(value, 32)
+	case "double", "scaled_float":
+		parsed, err = parseFloat(value, 64)
+	case "ip":
+		parsed, err = parseIP(value)
+	case "binary":
+		parsed, err = parseBinary(value)
+	case "array":
+		//an array of strings stored in a column that is not an avro array
+		return parseArray(avro.Type{Type: "array"}, value, path)
+	default:
+		parsed = value
+	}
+
+	if err != nil {
+		return nil, newFieldParseError("", path, XJoinType(valueType), value, err)
+	}
+	return parsed, nil
+}
+
+// jsonValue returns the value of a nested record or map, which the database can return as JSON text
+func jsonValue(value interface{}) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case string:
+		return jsonValue([]byte(typedValue))
+	case []byte:
+		var parsed interface{}
+		err := json.Unmarshal(typedValue, &parsed)
+		return parsed, err
+	default:
+		return value, nil
+	}
+}
+
+// parseRecord parses the fields of a nested record. Keys that are not fields of the record are ignored.
+func parseRecord(recordType avro.Type, value interface{}, path string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	decoded, err := jsonValue(value)
+	if err != nil {
+		return nil, newFieldParseError("", path, XJoinType(recordType), value, err)
+	}
+	fields, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, newFieldParseError("", path, XJoinType(recordType), value, errUnexpectedType)
+	}
+
+	parsed := make(map[string]interface{}, len(recordType.Fields))
+	for _, field := range recordType.Fields {
+		parsed[field.Name], err = parseValue(field.Type, fields[field.Name], path+"."+field.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// parseMap parses each value of a map as the values type of the map
+func parseMap(mapType avro.Type, value interface{}, path string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	decoded, err := jsonValue(value)
+	if err != nil {
+		return nil, newFieldParseError("", path, XJoinType(mapType), value, err)
+	}
+	values, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, newFieldParseError("", path, XJoinType(mapType), value, errUnexpectedType)
+	}
+
+	parsed := make(map[string]interface{}, len(values))
+	for key, mapValue := range values {
+		parsed[key], err = parseValue(*mapType.Values, mapValue, path+"."+key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// parseString reads text, varchar, uuid and enum columns. NULL is an empty string.
+func parseString(value interface{}) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typedValue, nil
+	case []byte:
+		return string(typedValue), nil
+	default:
+		return nil, errUnexpectedType
+	}
+}
+
+// parseTimestamp reads timestamp and timestamptz columns, RFC 3339 strings and epoch milliseconds as UTC times
+func parseTimestamp(value interface{}) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return typedValue.UTC(), nil
+	case string:
+		return parseTimestampString(typedValue)
+	case []byte:
+		return parseTimestampString(string(typedValue))
+	case float64:
+		return time.UnixMilli(int64(typedValue)).UTC(), nil
+	case int64:
+		return time.UnixMilli(typedValue).UTC(), nil
+	default:
+		return nil, errUnexpectedType
+	}
+}
+
+func parseTimestampString(value string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var parsed time.Time
+		parsed, err = time.Parse(layout, value)
+		if err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseDate reads date columns and dates in the index as midnight UTC of the date
+func parseDate(value interface{}) (interface{}, error) {
+	var date time.Time
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		date = typedValue
+	case string, []byte:
+		text := fmt.Sprintf("%s", typedValue)
+		parsed, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			parsed, err = parseTimestampString(text)
+			if err != nil {
+				return nil, err
+			}
+		}
+		date = parsed
+	case float64, int64:
+		parsed, err := parseTimestamp(typedValue)
+		if err != nil {
+			return nil, err
+		}
+		date = parsed.(time.Time)
+	default:
+		return nil, errUnexpectedType
+	}
+
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseJSON reads json and jsonb columns. The value can be an object, an array or a scalar.
+func parseJSON(value interface{}) (interface{}, error) {
+	var text []byte
+	switch typedValue := value.(type) {
+	case nil:
+		var parsedField map[string]interface{}
+		return parsedField, nil
+	case string:
+		text = []byte(typedValue)
+	case []byte:
+		text = typedValue
+	case map[string]interface{}, []interface{}:
+		return typedValue, nil
+	default:
+		return nil, errUnexpectedType
+	}
+
+	var parsedField interface{}
+	err := json.Unmarshal(text, &parsedField)
+	if err != nil {
+		return nil, err
+	}
+	return parsedField, nil
+}
+
+// parseBoolean also reads the 0 and 1 of integer columns used as booleans
+func parseBoolean(value interface{}) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return typedValue, nil
+	case int64:
+		return typedValue != 0, nil
+	case string:
+		return strconv.ParseBool(typedValue)
+	case []byte:
+		return strconv.ParseBool(string(typedValue))
+	default:
+		return nil, errUnexpectedType
+	}
+}
+
+// parseInteger reads smallint, integer and bigint columns as int64. The index stores integers as JSON numbers.
+func parseInteger(value interface{}, bits int) (interface{}, error) {
+	var parsed int64
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		parsed = typedValue
+	case int32:
+		parsed = int64(typedValue)
+	case int:
+		parsed = int64(typedValue)
+	case float64:
+		if typedValue != math.Trunc(typedValue) {
+			return nil, fmt.Errorf("%v is not an integer", typedValue)
+		}
+		parsed = int64(typedValue)
+	case string, []byte:
+		var err error
+		parsed, err = strconv.ParseInt(fmt.Sprintf("%s", typedValue), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errUnexpectedType
+	}
+
+	if bits < 64 && (parsed < -(1<<(bits-1)) || parsed >= 1<<(bits-1)) {
+		return nil, fmt.Errorf("%d is out of range of a %d bit integer", parsed, bits)
+	}
+	return parsed, nil
+}
+
+// parseFloat reads real, double precision and numeric columns as float64. A float is rounded to 32 bits because
+// the database returns the nearest float32 of a real, while the index keeps the original JSON number.
+func parseFloat(value interface{}, bits int) (interface{}, error) {
+	var parsed float64
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		parsed = typedValue
+	case float32:
+		parsed = float64(typedValue)
+	case int64:
+		parsed = float64(typedValue)
+	case string, []byte:
+		var err error
+		parsed, err = strconv.ParseFloat(fmt.Sprintf("%s", typedValue), 64)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errUnexpectedType
+	}
+
+	if bits == 32 {
+		parsed = float64(float32(parsed))
+	}
+	return parsed, nil
+}
+
+// parseIP reads inet and cidr columns. A host prefix is the address, e.g. 10.0.0.1/32 is 10.0.0.1.
+func parseIP(value interface{}) (interface{}, error) {
+	var text string
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		text = typedValue
+	case []byte:
+		text = string(typedValue)
+	default:
+		return nil, errUnexpectedType
+	}
+
+	if strings.Contains(text, "/") {
+		prefix, err := netip.ParsePrefix(text)
+		if err != nil {
+			return nil, err
+		}
+		if prefix.Bits() == prefix.Addr().BitLen() {
+			return prefix.Addr().String(), nil
+		}
+		return prefix.Masked().String(), nil
+	}
+
+	addr, err := netip.ParseAddr(text)
+	if err != nil {
+		return nil, err
+	}
+	return addr.String(), nil
+}
+
+// parseBinary reads bytea columns as the base64 string the index stores
+func parseBinary(value interface{}) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(typedValue), nil
+	case string:
+		return typedValue, nil
+	default:
+		return nil, errUnexpectedType
+	}
+}
+
+// parseArray reads Postgres array literals, JSON arrays and index arrays, parsing each element as the items type.
+// NULL is an empty array because the index does not distinguish them.
+func parseArray(arrayType avro.Type, value interface{}, path string) (interface{}, error) {
+	itemType := avro.Type{Type: "string"}
+	if arrayType.Items != nil {
+		itemType = *arrayType.Items
+	}
+
+	var elements []interface{}
+	switch typedValue := value.(type) {
+	case nil:
+	case []interface{}:
+		elements = typedValue
+	case string:
+		return parseArray(arrayType, []byte(typedValue), path)
+	case []byte:
+		if strings.HasPrefix(string(typedValue), "[") {
+			err := json.Unmarshal(typedValue, &elements)
+			if err != nil {
+				return nil, newFieldParseError("", path, XJoinType(arrayType), value, err)
+			}
+			break
+		}
+
+		var literal []sql.NullString
+		err := pq.GenericArray{A: &literal}.Scan(typedValue)
+		if err != nil {
+			return nil, newFieldParseError("", path, XJoinType(arrayType), value, err)
+		}
+		for _, element := range literal {
+			if element.Valid {
+				elements = append(elements, element.String)
+			} else {
+				elements = append(elements, nil)
+			}
+		}
+	default:
+		return nil, newFieldParseError("", path, XJoinType(arrayType), value, errUnexpectedType)
+	}
+
+	parsed := make([]interface{}, 0, len(elements))
+	for i, element := range elements {
+		parsedElement, err := parseValue(itemType, element, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, parsedElement)
+	}
+	return parsed, nil
+}
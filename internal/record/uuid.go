@@ -0,0 +1,52 @@
+package record
+
+import (
+	"strings"
+)
+
+// uuidLayout is the canonical text of a UUID, x is a lowercase hex digit
+const uuidLayout = "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+
+const hexDigits = "0123456789abcdef"
+
+// UUIDChildPrefixes returns the UUID prefixes one hex digit longer than prefix. The hyphen before the next digit is
+// part of each child, so the children of "abcdef01" are "abcdef01-0" to "abcdef01-f". A whole UUID has no children.
+func UUIDChildPrefixes(prefix string) (children []string) {
+	if len(prefix) >= len(uuidLayout) {
+		return nil
+	}
+	if uuidLayout[len(prefix)] == '-' {
+		prefix += "-"
+	}
+	for _, digit := range hexDigits {
+		children = append(children, prefix+string(digit))
+	}
+	return children
+}
+
+// UUIDPrefixRange returns the UUIDs that bound the ids starting with prefix, lower <= id < upper. The bounds are whole
+// UUIDs so they can be compared with a native uuid column. Upper is empty when no UUID follows the prefix and both
+// are empty for the empty prefix.
+func UUIDPrefixRange(prefix string) (lower string, upper string) {
+	if prefix == "" {
+		return "", ""
+	}
+
+	next := []byte(prefix)
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i] == '-' {
+			continue
+		}
+		if next[i] != 'f' {
+			next[i] = hexDigits[strings.IndexByte(hexDigits, next[i])+1]
+			return padUUID(prefix), padUUID(string(next))
+		}
+		next[i] = '0'
+	}
+	return padUUID(prefix), ""
+}
+
+// padUUID fills the rest of the UUID layout after prefix with zeros
+func padUUID(prefix string) string {
+	return prefix + strings.ReplaceAll(uuidLayout[len(prefix):], "x", "0")
+}
@@ -0,0 +1,34 @@
+package record_test
+
+import (
+	. "github.com/RedHatInsights/xjoin-validation/internal/record"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UUID prefixes", func() {
+	It("should split a prefix by the next hex digit and include the hyphen before it", func() {
+		Expect(UUIDChildPrefixes("")).To(HaveLen(16))
+		Expect(UUIDChildPrefixes("ab")).To(ContainElements("ab0", "abf"))
+		Expect(UUIDChildPrefixes("abcdef01")).To(ContainElements("abcdef01-0", "abcdef01-f"))
+		Expect(UUIDChildPrefixes("abcdef01-2345-6789-abcd-ef0123456789")).To(BeEmpty())
+	})
+
+	It("should bound the ids of a prefix with whole UUIDs", func() {
+		lower, upper := UUIDPrefixRange("ab")
+		Expect(lower).To(Equal("ab000000-0000-0000-0000-000000000000"))
+		Expect(upper).To(Equal("ac000000-0000-0000-0000-000000000000"))
+
+		lower, upper = UUIDPrefixRange("abcdef0f-f")
+		Expect(lower).To(Equal("abcdef0f-f000-0000-0000-000000000000"))
+		Expect(upper).To(Equal("abcdef10-0000-0000-0000-000000000000"))
+
+		lower, upper = UUIDPrefixRange("ff")
+		Expect(lower).To(Equal("ff000000-0000-0000-0000-000000000000"))
+		Expect(upper).To(BeEmpty())
+
+		lower, upper = UUIDPrefixRange("")
+		Expect(lower).To(BeEmpty())
+		Expect(upper).To(BeEmpty())
+	})
+})
@@ -0,0 +1,128 @@
+package remediation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/go-errors/errors"
+)
+
+// Action is what would fix a mismatched record
+type Action string
+
+const (
+	ActionReindex     Action = "reindex"     //write the database row to the index
+	ActionDelete      Action = "delete"      //delete the document from the index
+	ActionInvestigate Action = "investigate" //reindexing is not expected to fix the record
+)
+
+// Entry is one line of the NDJSON remediation list
+type Entry struct {
+	ID       string                     `json:"id"`
+	Action   Action                     `json:"action"`
+	Category validator.MismatchCategory `json:"category"`
+	Index    string                     `json:"index"`
+	Table    string                     `json:"table"`
+}
+
+func ActionFor(category validator.MismatchCategory) Action {
+	switch category {
+	case validator.CategoryMissingInIndex, validator.CategoryStale:
+		return ActionReindex
+	case validator.CategoryOrphanedInIndex:
+		return ActionDelete
+	default:
+		//in flight records are expected to fix themselves. Parse and transformation errors need a code change, and a
+		//content mismatch of a document that is not older than the row is not explained by a missed update.
+		return ActionInvestigate
+	}
+}
+
+// Entries returns an entry for every mismatched id sorted by id
+func Entries(mismatches validator.CategorizedMismatches, index string, table string) (entries []Entry) {
+	for id, category := range mismatches {
+		entries = append(entries, Entry{
+			ID:       id,
+			Action:   ActionFor(category),
+			Category: category,
+			Index:    index,
+			Table:    table,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return
+}
+
+// Write writes the entries as NDJSON to the destination.
+// The destination is "stdout", an http(s) URL the entries are POSTed to, or a file path.
+func Write(destination string, entries []Entry) (err error) {
+	if destination == "stdout" {
+		return encode(os.Stdout, entries)
+	} else if strings.HasPrefix(destination, "http://") || strings.HasPrefix(destination, "https://") {
+		return post(destination, entries)
+	}
+
+	file, err := os.Create(destination)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil && closeErr != nil {
+			err = errors.Wrap(closeErr, 0)
+		}
+	}()
+
+	return encode(file, entries)
+}
+
+func encode(writer io.Writer, entries []Entry) error {
+	buffered := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(buffered)
+	for _, entry := range entries {
+		err := encoder.Encode(entry)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+	}
+
+	err := buffered.Flush()
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
+
+func post(url string, entries []Entry) error {
+	var body bytes.Buffer
+	err := encode(&body, entries)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	client := http.Client{Timeout: 30 * time.Second}
+	res, err := client.Post(url, "application/x-ndjson", &body)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		bodyBytes, _ := ioutil.ReadAll(res.Body)
+		return errors.Wrap(fmt.Errorf(
+			"invalid response code when posting remediation list. StatusCode: %v, Body: %s", res.StatusCode, bodyBytes), 0)
+	}
+	return nil
+}
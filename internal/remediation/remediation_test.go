@@ -0,0 +1,64 @@
+package remediation_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/remediation"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Remediation list", func() {
+	mismatches := CategorizedMismatches{
+		"3": CategoryParseError,
+		"1": CategoryMissingInIndex,
+		"2": CategoryOrphanedInIndex,
+		"4": CategoryStale,
+	}
+
+	It("should map every mismatch to an action", func() {
+		entries := remediation.Entries(mismatches, "xjoin.inventory.hosts", "hosts")
+		Expect(entries).To(Equal([]remediation.Entry{
+			{ID: "1", Action: remediation.ActionReindex, Category: CategoryMissingInIndex, Index: "xjoin.inventory.hosts", Table: "hosts"},
+			{ID: "2", Action: remediation.ActionDelete, Category: CategoryOrphanedInIndex, Index: "xjoin.inventory.hosts", Table: "hosts"},
+			{ID: "3", Action: remediation.ActionInvestigate, Category: CategoryParseError, Index: "xjoin.inventory.hosts", Table: "hosts"},
+			{ID: "4", Action: remediation.ActionReindex, Category: CategoryStale, Index: "xjoin.inventory.hosts", Table: "hosts"},
+		}))
+	})
+
+	It("should write the entries as NDJSON to a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "remediation.ndjson")
+		err := remediation.Write(path, remediation.Entries(mismatches, "index", "hosts"))
+		Expect(err).ToNot(HaveOccurred())
+
+		content, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[1]).To(Equal(`{"id":"2","action":"delete","category":"orphanedInIndex","index":"index","table":"hosts"}`))
+	})
+
+	It("should post the entries as NDJSON to an http endpoint", func() {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		var body string
+		httpmock.RegisterResponder("POST", "http://resync.svc/remediation",
+			func(req *http.Request) (*http.Response, error) {
+				Expect(req.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+				bodyBytes, _ := ioutil.ReadAll(req.Body)
+				body = string(bodyBytes)
+				return httpmock.NewStringResponse(202, ""), nil
+			})
+
+		err := remediation.Write("http://resync.svc/remediation", remediation.Entries(mismatches, "index", "hosts"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(body, "\n")).To(Equal(4))
+	})
+})
@@ -0,0 +1,215 @@
+package repair
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
+	"github.com/RedHatInsights/xjoin-validation/internal/remediation"
+	"github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/go-errors/errors"
+)
+
+// Method is how records that are missing or stale in the index are repaired
+type Method string
+
+const (
+	MethodTouch Method = "touch" //run the touch SQL so the connector re-emits the row
+	MethodIndex Method = "index" //write the database row directly to the index, without the pipeline's transformations
+)
+
+type SourceStore interface {
+	GetRowsByIDs(ids []string) (records []map[string]interface{}, err error)
+	TouchRows(ids []string, touchSQL string) error
+}
+
+type IndexStore interface {
+	IndexDocuments(documents map[string]map[string]interface{}) error
+	DeleteDocuments(ids []string) error
+}
+
+type Repairer struct {
+	SourceStore
+	IndexStore
+	Method        Method
+	TouchSQL      string
+	DryRun        bool      //when true, only write the audit log
+	RatePerSecond int       //the maximum number of records repaired per second, 0 is unlimited
+	BatchSize     int       //the number of records repaired in each request
+	Audit         io.Writer //receives an NDJSON AuditEntry for every record
+	RootNode      string
+	Log           logger.Log
+	Sleep         func(time.Duration) //defaults to time.Sleep
+}
+
+type AuditEntry struct {
+	Time     time.Time                  `json:"time"`
+	ID       string                     `json:"id"`
+	Category validator.MismatchCategory `json:"category"`
+	Action   remediation.Action         `json:"action"`
+	Method   string                     `json:"method"` //touch, index, delete or skip
+	DryRun   bool                       `json:"dryRun"`
+	Error    string                     `json:"error,omitempty"`
+}
+
+type Result struct {
+	Reindexed int `json:"reindexed"`
+	Deleted   int `json:"deleted"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+// Repair deletes documents that are only in the index and reindexes records that are missing or stale.
+// Records that need to be investigated are skipped.
+func (r *Repairer) Repair(entries []remediation.Entry) (result Result, err error) {
+	if r.Method != MethodTouch && r.Method != MethodIndex {
+		return result, errors.Wrap(errors.New("invalid repair method: "+string(r.Method)+". Expected touch or index"), 0)
+	}
+	if r.BatchSize < 1 {
+		r.BatchSize = 100
+	}
+	if r.Sleep == nil {
+		r.Sleep = time.Sleep
+	}
+	if r.Audit == nil {
+		r.Audit = io.Discard
+	}
+
+	var reindex, remove []remediation.Entry
+	for _, entry := range entries {
+		switch entry.Action {
+		case remediation.ActionReindex:
+			reindex = append(reindex, entry)
+		case remediation.ActionDelete:
+			remove = append(remove, entry)
+		default:
+			result.Skipped++
+			err = r.audit(entry, "skip", nil)
+			if err != nil {
+				return result, errors.Wrap(err, 0)
+			}
+		}
+	}
+
+	err = r.inBatches(remove, func(batch []remediation.Entry) error {
+		return r.repairBatch(batch, "delete", r.delete, &result.Deleted, &result.Failed)
+	})
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+
+	err = r.inBatches(reindex, func(batch []remediation.Entry) error {
+		return r.repairBatch(batch, string(r.Method), r.reindex, &result.Reindexed, &result.Failed)
+	})
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+
+	r.Log.Info("Repair complete", "dryRun", r.DryRun, "result", result)
+
+	return
+}
+
+// inBatches calls repairBatch for each batch and sleeps between batches to stay below RatePerSecond
+func (r *Repairer) inBatches(entries []remediation.Entry, repairBatch func([]remediation.Entry) error) error {
+	for start := 0; start < len(entries); start += r.BatchSize {
+		end := start + r.BatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		batchStart := time.Now()
+		err := repairBatch(entries[start:end])
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+
+		if r.RatePerSecond > 0 {
+			minDuration := time.Duration(float64(end-start) / float64(r.RatePerSecond) * float64(time.Second))
+			if elapsed := time.Since(batchStart); elapsed < minDuration {
+				r.Sleep(minDuration - elapsed)
+			}
+		}
+	}
+	return nil
+}
+
+// repairBatch runs the repair for a batch and audits each record.
+// A failed batch is audited and counted, it does not stop the repair.
+func (r *Repairer) repairBatch(batch []remediation.Entry, method string,
+	repair func([]string) error, repaired *int, failed *int) error {
+
+	ids := make([]string, 0, len(batch))
+	for _, entry := range batch {
+		ids = append(ids, entry.ID)
+	}
+
+	var repairErr error
+	if !r.DryRun {
+		repairErr = repair(ids)
+	}
+	if repairErr != nil {
+		r.Log.Warn("Unable to repair records", "method", method, "ids", ids, "error", repairErr)
+		*failed += len(batch)
+	} else {
+		*repaired += len(batch)
+	}
+
+	for _, entry := range batch {
+		err := r.audit(entry, method, repairErr)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+	}
+	return nil
+}
+
+func (r *Repairer) delete(ids []string) error {
+	return r.IndexStore.DeleteDocuments(ids)
+}
+
+func (r *Repairer) reindex(ids []string) error {
+	if r.Method == MethodTouch {
+		return r.SourceStore.TouchRows(ids, r.TouchSQL)
+	}
+
+	records, err := r.SourceStore.GetRowsByIDs(ids)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	documents := make(map[string]map[string]interface{})
+	for _, record := range records {
+		rootNodeMap, ok := record[r.RootNode].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, ok := rootNodeMap["id"].(string) //TODO: parse ID field from schema
+		if ok {
+			documents[id] = record
+		}
+	}
+
+	return r.IndexStore.IndexDocuments(documents)
+}
+
+func (r *Repairer) audit(entry remediation.Entry, method string, repairErr error) error {
+	auditEntry := AuditEntry{
+		Time:     time.Now().UTC(),
+		ID:       entry.ID,
+		Category: entry.Category,
+		Action:   entry.Action,
+		Method:   method,
+		DryRun:   r.DryRun,
+	}
+	if repairErr != nil {
+		auditEntry.Error = repairErr.Error()
+	}
+
+	err := json.NewEncoder(r.Audit).Encode(auditEntry)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package repair_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/remediation"
+	"github.com/RedHatInsights/xjoin-validation/internal/repair"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Repair", func() {
+	var sourceStore *test.InMemoryStore
+	var indexStore *test.InMemoryStore
+	var audit bytes.Buffer
+	var entries []remediation.Entry
+	now := time.Now()
+
+	BeforeEach(func() {
+		audit.Reset()
+		sourceStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{
+			test.HostRecord("1", "missing", now),
+			test.HostRecord("3", "new", now),
+		}}
+		indexStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{
+			test.HostRecord("2", "orphaned", now),
+			test.HostRecord("3", "old", now.Add(-time.Hour)),
+		}}
+		entries = remediation.Entries(CategorizedMismatches{
+			"1": CategoryMissingInIndex,
+			"2": CategoryOrphanedInIndex,
+			"3": CategoryStale,
+			"4": CategoryParseError,
+		}, "index", "hosts")
+	})
+
+	auditEntries := func() (auditEntries []repair.AuditEntry) {
+		for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+			var entry repair.AuditEntry
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			auditEntries = append(auditEntries, entry)
+		}
+		return
+	}
+
+	It("should delete orphaned documents and touch rows that are missing or stale", func() {
+		repairer := repair.Repairer{
+			SourceStore: sourceStore,
+			IndexStore:  indexStore,
+			Method:      repair.MethodTouch,
+			Audit:       &audit,
+		}
+
+		result, err := repairer.Repair(entries)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(repair.Result{Reindexed: 2, Deleted: 1, Skipped: 1}))
+		Expect(sourceStore.Touched).To(Equal([]string{"1", "3"}))
+
+		documents, _ := indexStore.GetDocumentsByIDs([]string{"1", "2", "3"})
+		Expect(documents).To(Equal([]map[string]interface{}{test.HostRecord("3", "old", now.Add(-time.Hour))}))
+		Expect(auditEntries()).To(HaveLen(4))
+	})
+
+	It("should delete orphaned documents and write rows to the index", func() {
+		repairer := repair.Repairer{
+			SourceStore: sourceStore,
+			IndexStore:  indexStore,
+			Method:      repair.MethodIndex,
+			Audit:       &audit,
+			RootNode:    "host",
+		}
+
+		result, err := repairer.Repair(entries)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(repair.Result{Reindexed: 2, Deleted: 1, Skipped: 1}))
+		Expect(sourceStore.Touched).To(BeEmpty())
+
+		documents, _ := indexStore.GetDocumentsByIDs([]string{"1", "2", "3"})
+		Expect(documents).To(ConsistOf(test.HostRecord("1", "missing", now), test.HostRecord("3", "new", now)))
+		for _, entry := range auditEntries() {
+			Expect(entry.Method).To(BeElementOf("index", "delete", "skip"))
+		}
+	})
+
+	It("should reject an unknown repair method", func() {
+		repairer := repair.Repairer{
+			SourceStore: sourceStore,
+			IndexStore:  indexStore,
+			Method:      "rewrite",
+			Audit:       &audit,
+		}
+
+		_, err := repairer.Repair(entries)
+		Expect(err).To(HaveOccurred())
+		Expect(indexStore.Records).To(HaveLen(2))
+	})
+
+	It("should only write the audit log during a dry run", func() {
+		repairer := repair.Repairer{
+			SourceStore: sourceStore,
+			IndexStore:  indexStore,
+			Method:      repair.MethodTouch,
+			DryRun:      true,
+			Audit:       &audit,
+		}
+
+		_, err := repairer.Repair(entries)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(indexStore.Records).To(HaveLen(2))
+		Expect(sourceStore.Touched).To(BeEmpty())
+		for _, entry := range auditEntries() {
+			Expect(entry.DryRun).To(BeTrue())
+		}
+	})
+
+	It("should sleep between batches to respect the rate limit", func() {
+		var slept []time.Duration
+		repairer := repair.Repairer{
+			SourceStore:   sourceStore,
+			IndexStore:    indexStore,
+			Method:        repair.MethodTouch,
+			RatePerSecond: 1,
+			BatchSize:     1,
+			Audit:         &audit,
+			Sleep: func(duration time.Duration) {
+				slept = append(slept, duration)
+			},
+		}
+
+		_, err := repairer.Repair(entries)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(slept).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,418 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"golang.org/x/exp/slices"
+)
+
+// InMemoryStore is a fake SourceStore and IndexStore backed by a slice of parsed records
+type InMemoryStore struct {
+	RootNode string
+	Records  []map[string]interface{}
+	Indices  []string
+	Touched  []string
+
+	RecordsRead int64 //the number of full records read by GetRowsByIDs and GetDocumentsByIDs
+}
+
+// HostRecord returns a record of the host root node with the fields the in-memory store tests compare
+func HostRecord(id string, displayName string, modifiedOn time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"host": map[string]interface{}{
+			"id":           id,
+			"display_name": displayName,
+			"modified_on":  modifiedOn,
+		},
+	}
+}
+
+// HostStores returns a source store and an index store of the host root node with a copy of the records each,
+// so the records of one store can be changed without changing the other
+func HostStores(records ...map[string]interface{}) (sourceStore *InMemoryStore, indexStore *InMemoryStore) {
+	return &InMemoryStore{RootNode: "host", Records: copyRecords(records)},
+		&InMemoryStore{RootNode: "host", Records: copyRecords(records)}
+}
+
+func copyRecords(records []map[string]interface{}) (copied []map[string]interface{}) {
+	for _, record := range records {
+		copiedRecord := make(map[string]interface{})
+		for node, value := range record {
+			if fields, ok := value.(map[string]interface{}); ok {
+				copiedFields := make(map[string]interface{})
+				for field, fieldValue := range fields {
+					copiedFields[field] = fieldValue
+				}
+				value = copiedFields
+			}
+			copiedRecord[node] = value
+		}
+		copied = append(copied, copiedRecord)
+	}
+	return
+}
+
+func (s *InMemoryStore) recordID(record map[string]interface{}) string {
+	id, _ := record[s.RootNode].(map[string]interface{})["id"].(string)
+	return id
+}
+
+func (s *InMemoryStore) count() int {
+	return len(s.Records)
+}
+
+func (s *InMemoryStore) idsByModifiedOn(start time.Time, end time.Time) (ids []string) {
+	for _, record := range s.Records {
+		modifiedOn, ok := record[s.RootNode].(map[string]interface{})["modified_on"].(time.Time)
+		if ok && modifiedOn.After(start) && modifiedOn.Before(end) {
+			ids = append(ids, s.recordID(record))
+		}
+	}
+	sort.Strings(ids)
+	return
+}
+
+func (s *InMemoryStore) sortedIDs() (ids []string) {
+	for _, record := range s.Records {
+		ids = append(ids, s.recordID(record))
+	}
+	sort.Strings(ids)
+	return
+}
+
+// idsModifiedBefore returns the sorted ids of the records that are not modified after modifiedBefore
+func (s *InMemoryStore) idsModifiedBefore(modifiedBefore time.Time) (ids []string) {
+	for _, record := range s.Records {
+		modifiedOn, _ := record[s.RootNode].(map[string]interface{})["modified_on"].(time.Time)
+		if !modifiedOn.After(modifiedBefore) {
+			ids = append(ids, s.recordID(record))
+		}
+	}
+	sort.Strings(ids)
+	return
+}
+
+func (s *InMemoryStore) idsByIDList(ids []string) (responseIds []string) {
+	for _, record := range s.Records {
+		if slices.Contains(ids, s.recordID(record)) {
+			responseIds = append(responseIds, s.recordID(record))
+		}
+	}
+	return
+}
+
+func (s *InMemoryStore) recordsByIDs(ids []string) (records []map[string]interface{}) {
+	for _, record := range s.Records {
+		if slices.Contains(ids, s.recordID(record)) {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return s.recordID(records[i]) < s.recordID(records[j])
+	})
+	return
+}
+
+func (s *InMemoryStore) CountTable() (int, error) {
+	return s.count(), nil
+}
+
+func (s *InMemoryStore) CountIndex() (int, error) {
+	return s.count(), nil
+}
+
+func (s *InMemoryStore) GetIDsByModifiedOn(start time.Time, end time.Time) ([]string, error) {
+	return s.idsByModifiedOn(start, end), nil
+}
+
+func (s *InMemoryStore) GetIDsByIDList(ids []string) ([]string, error) {
+	return s.idsByIDList(ids), nil
+}
+
+func (s *InMemoryStore) GetRowsByIDs(ids []string) ([]map[string]interface{}, error) {
+	records := s.recordsByIDs(ids)
+	atomic.AddInt64(&s.RecordsRead, int64(len(records)))
+	return records, nil
+}
+
+func (s *InMemoryStore) GetDocumentsByIDs(ids []string) ([]map[string]interface{}, error) {
+	records := s.recordsByIDs(ids)
+	atomic.AddInt64(&s.RecordsRead, int64(len(records)))
+	return records, nil
+}
+
+func (s *InMemoryStore) hashesByIDs(ids []string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, rec := range s.recordsByIDs(ids) {
+		hash, err := recordHash(rec)
+		if err != nil {
+			return hashes, err
+		}
+		hashes[s.recordID(rec)] = hash
+	}
+	return hashes, nil
+}
+
+// recordHash returns the SHA-256 hex digest of the canonical JSON of a parsed record, standing in for the hashes the
+// database computes and the index stores. Map keys are sorted by encoding/json and timestamps are normalized to UTC,
+// so records that are deep equal have the same hash.
+func recordHash(rec map[string]interface{}) (string, error) {
+	canonical, err := json.Marshal(normalize(rec))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func normalize(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case time.Time:
+		return typedValue.UTC().Format(time.RFC3339Nano)
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(typedValue))
+		for key, nestedValue := range typedValue {
+			normalized[key] = normalize(nestedValue)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(typedValue))
+		for i, nestedValue := range typedValue {
+			normalized[i] = normalize(nestedValue)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+func (s *InMemoryStore) GetRowHashesByIDs(ids []string) (map[string]string, error) {
+	return s.hashesByIDs(ids)
+}
+
+func (s *InMemoryStore) GetDocumentHashesByIDs(ids []string) (map[string]string, error) {
+	return s.hashesByIDs(ids)
+}
+
+func (s *InMemoryStore) hashesByIDPrefix(prefix string, modifiedBefore time.Time) (map[string]string, error) {
+	var ids []string
+	for _, id := range s.idsModifiedBefore(modifiedBefore) {
+		if strings.HasPrefix(id, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	return s.hashesByIDs(ids)
+}
+
+func (s *InMemoryStore) bucketHashes(prefix string, modifiedBefore time.Time) (map[string]record.BucketHash, error) {
+	children := record.UUIDChildPrefixes(prefix)
+	if len(children) == 0 {
+		return nil, fmt.Errorf("a whole id cannot be split into buckets: %s", prefix)
+	}
+	hashes, err := s.hashesByIDPrefix(prefix, modifiedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[string]record.BucketHash)
+	for id, hash := range hashes {
+		key := id[:len(children[0])]
+		bucket := buckets[key]
+		bucket.Add(hash)
+		buckets[key] = bucket
+	}
+	return buckets, nil
+}
+
+func (s *InMemoryStore) GetRowBucketHashes(prefix string, modifiedBefore time.Time) (map[string]record.BucketHash, error) {
+	return s.bucketHashes(prefix, modifiedBefore)
+}
+
+func (s *InMemoryStore) GetDocumentBucketHashes(prefix string, modifiedBefore time.Time) (map[string]record.BucketHash, error) {
+	return s.bucketHashes(prefix, modifiedBefore)
+}
+
+func (s *InMemoryStore) GetRowHashesByIDPrefix(prefix string, _ int, modifiedBefore time.Time) (map[string]string, error) {
+	return s.hashesByIDPrefix(prefix, modifiedBefore)
+}
+
+func (s *InMemoryStore) GetDocumentHashesByIDPrefix(prefix string, _ int, modifiedBefore time.Time) (map[string]string, error) {
+	return s.hashesByIDPrefix(prefix, modifiedBefore)
+}
+
+func (s *InMemoryStore) countByWindow(field string, interval string, start time.Time, end time.Time) (map[time.Time]int, error) {
+	duration, err := validator.WindowDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[time.Time]int)
+	for _, record := range s.Records {
+		value, ok := record[s.RootNode].(map[string]interface{})[field].(time.Time)
+		if ok && !value.Before(start) && value.Before(end) {
+			counts[value.UTC().Truncate(duration)]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *InMemoryStore) CountTableByWindow(field string, interval string, start time.Time, end time.Time) (map[time.Time]int, error) {
+	return s.countByWindow(field, interval, start, end)
+}
+
+func (s *InMemoryStore) CountIndexByWindow(field string, interval string, start time.Time, end time.Time) (map[time.Time]int, error) {
+	return s.countByWindow(field, interval, start, end)
+}
+
+func (s *InMemoryStore) tenant(record map[string]interface{}, column string) string {
+	value := record[s.RootNode].(map[string]interface{})[column]
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func (s *InMemoryStore) countByTenant(column string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, record := range s.Records {
+		counts[s.tenant(record, column)]++
+	}
+	return counts, nil
+}
+
+func (s *InMemoryStore) tenantsByIDs(column string, ids []string) (map[string]string, error) {
+	tenants := make(map[string]string)
+	for _, record := range s.recordsByIDs(ids) {
+		tenants[s.recordID(record)] = s.tenant(record, column)
+	}
+	return tenants, nil
+}
+
+func (s *InMemoryStore) CountTableByTenant(column string) (map[string]int, error) {
+	return s.countByTenant(column)
+}
+
+func (s *InMemoryStore) CountIndexByTenant(field string) (map[string]int, error) {
+	return s.countByTenant(field)
+}
+
+func (s *InMemoryStore) GetRowTenantsByIDs(column string, ids []string) (map[string]string, error) {
+	return s.tenantsByIDs(column, ids)
+}
+
+func (s *InMemoryStore) GetDocumentTenantsByIDs(field string, ids []string) (map[string]string, error) {
+	return s.tenantsByIDs(field, ids)
+}
+
+func (s *InMemoryStore) ResolveIndices() ([]string, error) {
+	return s.Indices, nil
+}
+
+func (s *InMemoryStore) GetIDsAfter(afterID string, limit int, modifiedBefore time.Time) (ids []string, err error) {
+	for _, id := range s.idsModifiedBefore(modifiedBefore) {
+		if id > afterID && len(ids) < limit {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+func (s *InMemoryStore) GetIDsByIDRange(afterID string, lastID string, modifiedBefore time.Time) (ids []string, err error) {
+	for _, id := range s.idsModifiedBefore(modifiedBefore) {
+		if id > afterID && (lastID == "" || id <= lastID) {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+func (s *InMemoryStore) TouchRows(ids []string, _ string) error {
+	s.Touched = append(s.Touched, ids...)
+	return nil
+}
+
+func (s *InMemoryStore) IndexDocuments(documents map[string]map[string]interface{}) error {
+	ids := make([]string, 0, len(documents))
+	for id := range documents {
+		ids = append(ids, id)
+	}
+	_ = s.DeleteDocuments(ids)
+	for _, id := range ids {
+		s.Records = append(s.Records, documents[id])
+	}
+	return nil
+}
+
+func (s *InMemoryStore) DeleteDocuments(ids []string) error {
+	var records []map[string]interface{}
+	for _, record := range s.Records {
+		if !slices.Contains(ids, s.recordID(record)) {
+			records = append(records, record)
+		}
+	}
+	s.Records = records
+	return nil
+}
+
+// ConsistentStore is an InMemoryStore that supports the consistency mode snapshot and point in time
+type ConsistentStore struct {
+	InMemoryStore
+	SnapshotTime time.Time
+	WriteTime    time.Time
+	SnapshotOpen bool
+}
+
+func (s *ConsistentStore) BeginSnapshot() (time.Time, error) {
+	s.SnapshotOpen = true
+	return s.SnapshotTime, nil
+}
+
+func (s *ConsistentStore) EndSnapshot() error {
+	s.SnapshotOpen = false
+	return nil
+}
+
+func (s *ConsistentStore) OpenPointInTime() (time.Time, error) {
+	s.SnapshotOpen = true
+	return s.WriteTime, nil
+}
+
+func (s *ConsistentStore) ClosePointInTime() error {
+	s.SnapshotOpen = false
+	return nil
+}
+
+func (s *ConsistentStore) GetModifiedOnByIDs(ids []string) (map[string]time.Time, error) {
+	modifiedOn := make(map[string]time.Time)
+	for _, record := range s.recordsByIDs(ids) {
+		modifiedOn[s.recordID(record)], _ = record[s.RootNode].(map[string]interface{})["modified_on"].(time.Time)
+	}
+	return modifiedOn, nil
+}
+
+// InMemoryCheckpointStore is a fake CheckpointStore
+type InMemoryCheckpointStore struct {
+	Checkpoints map[string]validator.Checkpoint
+}
+
+func (s *InMemoryCheckpointStore) LoadCheckpoint(key string) (validator.Checkpoint, error) {
+	return s.Checkpoints[key], nil
+}
+
+func (s *InMemoryCheckpointStore) SaveCheckpoint(checkpoint validator.Checkpoint) error {
+	if s.Checkpoints == nil {
+		s.Checkpoints = make(map[string]validator.Checkpoint)
+	}
+	s.Checkpoints[checkpoint.Key] = checkpoint
+	return nil
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"database/sql"
+	"encoding/json"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/RedHatInsights/xjoin-validation/internal/avro"
 	"github.com/RedHatInsights/xjoin-validation/internal/database"
@@ -9,7 +10,10 @@ import (
 	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
 	"github.com/jarcoal/httpmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"io"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -67,8 +71,8 @@ func BeforeEach() TestEnv {
 	Expect(err).ToNot(HaveOccurred())
 
 	validator := Validator{
-		DBClient:           *dbClient,
-		ESClient:           *esClient,
+		SourceStore:        dbClient,
+		IndexStore:         esClient,
 		PeriodMin:          100,
 		LagCompSec:         0,
 		ValidateEverything: false,
@@ -84,3 +88,46 @@ func BeforeEach() TestEnv {
 		DBMock:    dbMock,
 	}
 }
+
+// QueryStores returns a database client on the sqlmock connection and an Elasticsearch client on the httpmock
+// transport, with the table, index and schema of BeforeEach when they are not set in the params
+func QueryStores(mockDB *sql.DB, dbParams database.DBParams, esParams elasticsearch.ESParams) (*database.DBClient, *elasticsearch.ESClient) {
+	schemaParser := avro.SchemaParser{FullSchemaString: LoadTestDataFile("avro/full")}
+	parsedSchema, err := schemaParser.Parse()
+	Expect(err).ToNot(HaveOccurred())
+
+	if dbParams.Table == "" {
+		dbParams.Table = "hosts"
+	}
+	dbParams.ParsedAvroSchema = parsedSchema
+	dbClient := database.NewTestDBClient(sqlx.NewDb(mockDB, "sqlmock"), dbParams)
+
+	if esParams.Url == "" {
+		esParams.Url = "http://mock-es:9200"
+	}
+	if esParams.Index == "" {
+		esParams.Index = "mockindex"
+	}
+	esParams.RootNode = parsedSchema.RootNode
+	esParams.ParsedAvroSchema = parsedSchema
+	esClient, err := elasticsearch.NewESClient(esParams)
+	Expect(err).ToNot(HaveOccurred())
+
+	return dbClient, esClient
+}
+
+// CaptureRequests registers a responder that responds with response and appends the JSON body of each request
+func CaptureRequests(method string, url string, response string) *[]map[string]interface{} {
+	bodies := &[]map[string]interface{}{}
+	httpmock.RegisterResponder(method, url, func(req *http.Request) (*http.Response, error) {
+		defer ginkgo.GinkgoRecover()
+		body, err := io.ReadAll(req.Body)
+		Expect(err).ToNot(HaveOccurred())
+
+		var parsed map[string]interface{}
+		Expect(json.Unmarshal(body, &parsed)).To(Succeed())
+		*bodies = append(*bodies, parsed)
+		return httpmock.NewStringResponse(200, response), nil
+	})
+	return bodies
+}
@@ -0,0 +1,154 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// MismatchCategory is the likely root cause of a mismatch
+type MismatchCategory string
+
+const (
+	CategoryMissingInIndex        MismatchCategory = "missingInIndex"        //the row is not in the index
+	CategoryOrphanedInIndex       MismatchCategory = "orphanedInIndex"       //the document is not in the database
+	CategoryStale                 MismatchCategory = "stale"                 //the index has an older copy of the row
+	CategoryInFlight              MismatchCategory = "inFlight"              //the row was modified too recently to be indexed
+	CategoryParseError            MismatchCategory = "parseError"            //the values are the same data with a different type or format
+	CategoryTransformedFieldError MismatchCategory = "transformedFieldError" //only transformed fields differ
+	CategoryContentMismatch       MismatchCategory = "contentMismatch"       //the index copy is as new as the row but its content differs
+)
+
+// CategorizedMismatches is a map of record id to the category of its mismatch
+type CategorizedMismatches map[string]MismatchCategory
+
+// MismatchCategories is the number of mismatches in each category
+type MismatchCategories struct {
+	MissingInIndex        int `json:"missingInIndex"`
+	OrphanedInIndex       int `json:"orphanedInIndex"`
+	Stale                 int `json:"stale"`
+	InFlight              int `json:"inFlight"`
+	ParseError            int `json:"parseError"`
+	TransformedFieldError int `json:"transformedFieldError"`
+	ContentMismatch       int `json:"contentMismatch"`
+}
+
+func (c CategorizedMismatches) add(ids []string, category MismatchCategory) {
+	for _, id := range ids {
+		c[id] = category
+	}
+}
+
+// merge adds the mismatches of other. Categories from a later validation phase replace earlier ones.
+func (c CategorizedMismatches) merge(other CategorizedMismatches) {
+	for id, category := range other {
+		c[id] = category
+	}
+}
+
+func (c CategorizedMismatches) Counts() (counts MismatchCategories) {
+	for _, category := range c {
+		switch category {
+		case CategoryMissingInIndex:
+			counts.MissingInIndex++
+		case CategoryOrphanedInIndex:
+			counts.OrphanedInIndex++
+		case CategoryStale:
+			counts.Stale++
+		case CategoryInFlight:
+			counts.InFlight++
+		case CategoryParseError:
+			counts.ParseError++
+		case CategoryTransformedFieldError:
+			counts.TransformedFieldError++
+		case CategoryContentMismatch:
+			counts.ContentMismatch++
+		}
+	}
+	return
+}
+
+// classifyContentMismatch determines the category of a record whose content differs between the database and index
+func (v *Validator) classifyContentMismatch(dbRecord map[string]interface{}, esDocument map[string]interface{}, diffs []string) MismatchCategory {
+	if esDocument == nil {
+		return CategoryMissingInIndex
+	}
+	if dbRecord == nil {
+		return CategoryOrphanedInIndex
+	}
+
+	dbRoot, _ := dbRecord[v.RootNode].(map[string]interface{})
+	esRoot, _ := esDocument[v.RootNode].(map[string]interface{})
+
+	//TODO: parse modified_on field name from avro schema
+	dbModifiedOn, dbOk := dbRoot["modified_on"].(time.Time)
+	esModifiedOn, esOk := esRoot["modified_on"].(time.Time)
+	if dbOk && esOk && dbModifiedOn.After(esModifiedOn) {
+		if dbModifiedOn.After(v.inFlightCutoff()) {
+			return CategoryInFlight
+		}
+		return CategoryStale
+	}
+
+	//the index copy is not older than the row, so the difference is not explained by a missed update
+	fields := diffFields(diffs, v.RootNode)
+	if len(fields) > 0 {
+		transformedOnly := true
+		normalizationOnly := true
+		for _, field := range fields {
+			if !slices.Contains(v.TransformedFields, v.RootNode+"."+field) {
+				transformedOnly = false
+			}
+			if !sameNormalizedValue(dbRoot[field], esRoot[field]) {
+				normalizationOnly = false
+			}
+		}
+
+		if transformedOnly {
+			return CategoryTransformedFieldError
+		} else if normalizationOnly {
+			return CategoryParseError
+		}
+	}
+
+	return CategoryContentMismatch
+}
+
+// inFlightCutoff is the time after which a modified row is not expected to be in the index yet
+func (v *Validator) inFlightCutoff() time.Time {
+	if v.ConsistencyMode {
+		return v.indexWriteTime
+	}
+	return v.Now.Add(-time.Duration(v.LagCompSec) * time.Second)
+}
+
+// diffFields returns the top level field names from deep.Equal diffs, e.g. slice[0].map[host].map[display_name]: a != b
+func diffFields(diffs []string, rootNode string) (fields []string) {
+	prefix := ".map[" + rootNode + "].map["
+	for _, diff := range diffs {
+		start := strings.Index(diff, prefix)
+		if start == -1 {
+			continue
+		}
+		field := diff[start+len(prefix):]
+		field = field[:strings.Index(field, "]")]
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return
+}
+
+// sameNormalizedValue is true when both values have the same string representation but are not equal,
+// e.g. "1" and 1 or the same instant in a different time zone
+func sameNormalizedValue(dbValue interface{}, esValue interface{}) bool {
+	if dbTime, ok := dbValue.(time.Time); ok {
+		if esTime, ok := esValue.(time.Time); ok {
+			return dbTime.Equal(esTime)
+		}
+	}
+	return reflect.TypeOf(dbValue) != reflect.TypeOf(esValue) && fmt.Sprint(dbValue) == fmt.Sprint(esValue)
+}
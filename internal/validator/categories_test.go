@@ -0,0 +1,117 @@
+package validator_test
+
+import (
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mismatch categories", func() {
+	var validator Validator
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			PeriodMin:         100,
+			LagCompSec:        30,
+			Now:               now,
+			RootNode:          "host",
+			TransformedFields: []string{"host.tags_structured"},
+			ContentChunkSize:  10,
+			ContentMaxThreads: 1,
+		}
+	})
+
+	validateContent := func(dbRecord map[string]interface{}, esDocument map[string]interface{}) ValidationResponse {
+		validator.SourceStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{dbRecord}}
+		validator.IndexStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{esDocument}}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		return response
+	}
+
+	It("should categorize ids that are only in one store", func() {
+		records := []map[string]interface{}{
+			test.HostRecord("1", "first", now.Add(-time.Hour)),
+			test.HostRecord("2", "second", now.Add(-time.Hour)),
+			test.HostRecord("3", "third", now.Add(-time.Hour)),
+			test.HostRecord("4", "fourth", now.Add(-time.Hour)),
+		}
+		validator.SourceStore = &test.InMemoryStore{RootNode: "host", Records: append(records, test.HostRecord("5", "fifth", now.Add(-time.Hour)))}
+		validator.IndexStore = &test.InMemoryStore{RootNode: "host", Records: append(records, test.HostRecord("6", "sixth", now.Add(-time.Hour)))}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{MissingInIndex: 1, OrphanedInIndex: 1}))
+		Expect(response.Mismatches).To(Equal(CategorizedMismatches{"5": CategoryMissingInIndex, "6": CategoryOrphanedInIndex}))
+	})
+
+	It("should categorize an index document with an older modified_on as stale", func() {
+		response := validateContent(
+			test.HostRecord("1", "new", now.Add(-time.Hour)),
+			test.HostRecord("1", "old", now.Add(-2*time.Hour)))
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{Stale: 1}))
+	})
+
+	It("should categorize a row modified after the index point in time as in flight", func() {
+		validator.ConsistencyMode = true
+		validator.SourceStore = &test.ConsistentStore{InMemoryStore: test.InMemoryStore{
+			RootNode: "host", Records: []map[string]interface{}{test.HostRecord("1", "new", now.Add(-40*time.Second))}}}
+		validator.IndexStore = &test.ConsistentStore{InMemoryStore: test.InMemoryStore{
+			RootNode: "host", Records: []map[string]interface{}{test.HostRecord("1", "old", now.Add(-time.Hour))}},
+			WriteTime: now.Add(-time.Minute)}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{InFlight: 1}))
+	})
+
+	It("should categorize the same value with a different type as a parse error", func() {
+		dbRecord := test.HostRecord("1", "first", now.Add(-time.Hour))
+		dbRecord["host"].(map[string]interface{})["stale_timestamp"] = "1"
+		esDocument := test.HostRecord("1", "first", now.Add(-time.Hour))
+		esDocument["host"].(map[string]interface{})["stale_timestamp"] = 1
+
+		response := validateContent(dbRecord, esDocument)
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{ParseError: 1}))
+	})
+
+	It("should categorize a difference in transformed fields as a transformed field error", func() {
+		dbRecord := test.HostRecord("1", "first", now.Add(-time.Hour))
+		dbRecord["host"].(map[string]interface{})["tags_structured"] = []interface{}{"a"}
+		esDocument := test.HostRecord("1", "first", now.Add(-time.Hour))
+		esDocument["host"].(map[string]interface{})["tags_structured"] = []interface{}{"b"}
+
+		response := validateContent(dbRecord, esDocument)
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{TransformedFieldError: 1}))
+	})
+
+	It("should categorize a difference in transformed and other fields as a content mismatch", func() {
+		dbRecord := test.HostRecord("1", "first", now.Add(-time.Hour))
+		dbRecord["host"].(map[string]interface{})["tags_structured"] = []interface{}{"a"}
+		esDocument := test.HostRecord("1", "other", now.Add(-time.Hour))
+		esDocument["host"].(map[string]interface{})["tags_structured"] = []interface{}{"b"}
+
+		response := validateContent(dbRecord, esDocument)
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{ContentMismatch: 1}))
+	})
+
+	It("should categorize different content with the same modified_on as a content mismatch", func() {
+		response := validateContent(
+			test.HostRecord("1", "first", now.Add(-time.Hour)),
+			test.HostRecord("1", "other", now.Add(-time.Hour)))
+		Expect(response.Details.Categories).To(Equal(MismatchCategories{ContentMismatch: 1}))
+	})
+
+	It("should categorize different content with a newer modified_on in the index as a content mismatch", func() {
+		response := validateContent(
+			test.HostRecord("1", "first", now.Add(-90*time.Minute)),
+			test.HostRecord("1", "other", now.Add(-time.Hour)))
+		Expect(response.Mismatches).To(Equal(CategorizedMismatches{"1": CategoryContentMismatch}))
+	})
+})
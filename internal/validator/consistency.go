@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/go-errors/errors"
+	"golang.org/x/exp/slices"
+)
+
+// SnapshotSourceStore is a SourceStore that can read every query from a single consistent snapshot
+type SnapshotSourceStore interface {
+	SourceStore
+	BeginSnapshot() (snapshotTime time.Time, err error)
+	EndSnapshot() error
+	GetModifiedOnByIDs(ids []string) (modifiedOn map[string]time.Time, err error)
+}
+
+// PointInTimeIndexStore is an IndexStore that can read every search from a single point in time
+type PointInTimeIndexStore interface {
+	IndexStore
+	OpenPointInTime() (writeTime time.Time, err error)
+	ClosePointInTime() error
+}
+
+type ConsistencyDetails struct {
+	SnapshotTime   time.Time `json:"snapshotTime"`   //when the database snapshot was taken
+	IndexWriteTime time.Time `json:"indexWriteTime"` //clock of the validator when the index point in time was opened
+	InFlightIDs    []string  `json:"inFlightIds,omitempty"`
+	InFlightCount  int       `json:"inFlightCount"`
+}
+
+func (c *ConsistencyDetails) addInFlight(ids []string) {
+	for _, id := range ids {
+		if !slices.Contains(c.InFlightIDs, id) {
+			c.InFlightIDs = append(c.InFlightIDs, id)
+		}
+	}
+	c.InFlightCount = len(c.InFlightIDs)
+}
+
+// beginConsistentRead opens the index point in time before the database snapshot.
+// Any row modified between the two can be missing from the index without the pipeline being broken.
+func (v *Validator) beginConsistentRead() (details ConsistencyDetails, err error) {
+	sourceStore, ok := v.SourceStore.(SnapshotSourceStore)
+	if !ok {
+		return details, errors.Wrap(errors.New("consistency mode is not supported by the source store"), 0)
+	}
+	indexStore, ok := v.IndexStore.(PointInTimeIndexStore)
+	if !ok {
+		return details, errors.Wrap(errors.New(
+			"consistency mode is not supported by the index backend, point in time requires Elasticsearch 7"), 0)
+	}
+
+	details.IndexWriteTime, err = indexStore.OpenPointInTime()
+	if err != nil {
+		return details, errors.Wrap(err, 0)
+	}
+	v.indexWriteTime = details.IndexWriteTime
+
+	details.SnapshotTime, err = sourceStore.BeginSnapshot()
+	if err != nil {
+		_ = indexStore.ClosePointInTime()
+		return details, errors.Wrap(err, 0)
+	}
+
+	v.Log.Info("Validating a consistent snapshot",
+		"snapshotTime", details.SnapshotTime, "indexWriteTime", details.IndexWriteTime)
+
+	return details, nil
+}
+
+func (v *Validator) endConsistentRead() {
+	err := v.SourceStore.(SnapshotSourceStore).EndSnapshot()
+	if err != nil {
+		v.Log.Warn("Unable to end database snapshot", "error", err)
+	}
+	err = v.IndexStore.(PointInTimeIndexStore).ClosePointInTime()
+	if err != nil {
+		v.Log.Warn("Unable to close index point in time", "error", err)
+	}
+}
+
+// splitInFlight removes the ids of rows modified after the index point in time was opened.
+// Those rows were changed while the validation was running and are not counted as mismatches.
+func (v *Validator) splitInFlight(ids []string) (mismatched []string, inFlight []string, err error) {
+	if !v.ConsistencyMode || len(ids) == 0 {
+		return ids, inFlight, nil
+	}
+
+	modifiedOn, err := v.SourceStore.(SnapshotSourceStore).GetModifiedOnByIDs(ids)
+	if err != nil {
+		return ids, inFlight, errors.Wrap(err, 0)
+	}
+
+	mismatched = make([]string, 0, len(ids))
+	for _, id := range ids {
+		modified, ok := modifiedOn[id]
+		if ok && modified.After(v.indexWriteTime) {
+			inFlight = append(inFlight, id)
+		} else {
+			mismatched = append(mismatched, id)
+		}
+	}
+	return mismatched, inFlight, nil
+}
+
+func removeIDs(ids []string, remove []string) (remaining []string) {
+	for _, id := range ids {
+		if !slices.Contains(remove, id) {
+			remaining = append(remaining, id)
+		}
+	}
+	return
+}
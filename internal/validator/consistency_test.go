@@ -0,0 +1,92 @@
+package validator_test
+
+import (
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Consistency mode", func() {
+	var validator Validator
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			PeriodMin:         100,
+			Now:               now,
+			RootNode:          "host",
+			ContentChunkSize:  10,
+			ContentMaxThreads: 10,
+			ConsistencyMode:   true,
+		}
+	})
+
+	It("should report rows modified after the point in time as in flight", func() {
+		indexed := []map[string]interface{}{
+			test.HostRecord("1", "first", now.Add(-time.Hour)),
+			test.HostRecord("2", "second", now.Add(-time.Hour)),
+			test.HostRecord("3", "third", now.Add(-time.Hour)),
+			test.HostRecord("4", "fourth", now.Add(-time.Hour)),
+		}
+		sourceStore := &test.ConsistentStore{
+			InMemoryStore: test.InMemoryStore{RootNode: "host", Records: append([]map[string]interface{}{
+				test.HostRecord("5", "fifth", now.Add(-time.Second)),
+			}, indexed...)},
+			SnapshotTime: now,
+		}
+		indexStore := &test.ConsistentStore{
+			InMemoryStore: test.InMemoryStore{RootNode: "host", Records: indexed},
+			WriteTime:     now.Add(-time.Minute),
+		}
+		validator.SourceStore = sourceStore
+		validator.IndexStore = indexStore
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.Consistency.SnapshotTime).To(Equal(now))
+		Expect(response.Details.Consistency.IndexWriteTime).To(Equal(now.Add(-time.Minute)))
+		Expect(response.Details.Consistency.InFlightIDs).To(Equal([]string{"5"}))
+		Expect(response.Details.Consistency.InFlightCount).To(Equal(1))
+		Expect(sourceStore.SnapshotOpen).To(BeFalse())
+		Expect(indexStore.SnapshotOpen).To(BeFalse())
+	})
+
+	It("should still report rows modified before the point in time as mismatched", func() {
+		indexed := []map[string]interface{}{
+			test.HostRecord("1", "first", now.Add(-time.Hour)),
+			test.HostRecord("2", "second", now.Add(-time.Hour)),
+			test.HostRecord("3", "third", now.Add(-time.Hour)),
+			test.HostRecord("4", "fourth", now.Add(-time.Hour)),
+		}
+		validator.SourceStore = &test.ConsistentStore{
+			InMemoryStore: test.InMemoryStore{RootNode: "host", Records: append([]map[string]interface{}{
+				test.HostRecord("5", "fifth", now.Add(-time.Hour)),
+			}, indexed...)},
+		}
+		validator.IndexStore = &test.ConsistentStore{
+			InMemoryStore: test.InMemoryStore{RootNode: "host", Records: indexed},
+			WriteTime:     now.Add(-time.Minute),
+		}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Reason).To(Equal("id mismatch"))
+		Expect(response.Details.IDs.IdsMissingFromElasticsearch).To(Equal([]string{"5"}))
+		Expect(response.Details.Consistency.InFlightCount).To(Equal(0))
+	})
+
+	It("should fail when the index backend does not support point in time", func() {
+		validator.SourceStore = &test.ConsistentStore{InMemoryStore: test.InMemoryStore{RootNode: "host"}}
+		validator.IndexStore = &test.InMemoryStore{RootNode: "host"}
+
+		_, err := validator.Validate()
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,13 +3,17 @@ package validator
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/RedHatInsights/xjoin-validation/internal/record"
 	goErrors "github.com/go-errors/errors"
 	"github.com/go-test/deep"
 	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+	"golang.org/x/exp/slices"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type ValidateContentResult struct {
@@ -19,6 +23,10 @@ type ValidateContentResult struct {
 	MismatchedRecords     validation.MismatchedRecords `json:"mismatchedRecords,omitempty"`
 	MismatchedIDs         []string                     `json:"mismatchedIDs,omitempty"`
 	TotalRecordsValidated int                          `json:"totalRecordsValidated,omitempty"`
+	InFlightIDs           []string                     `json:"inFlightIDs,omitempty"`
+	Categorized           CategorizedMismatches        `json:"-"`
+	Sample                *SampleEstimate              `json:"sample,omitempty"`
+	HashMismatchCount     int                          `json:"hashMismatchCount,omitempty"`
 }
 
 func (v *Validator) getDBRecord(id string, dbRecords []map[string]interface{}) (string, error) {
@@ -41,6 +49,37 @@ func (v *Validator) getDBRecord(id string, dbRecords []map[string]interface{}) (
 	return "", nil
 }
 
+func (v *Validator) findRecord(id string, records []map[string]interface{}) map[string]interface{} {
+	for _, record := range records {
+		rootNodeMap, ok := record[v.RootNode].(map[string]interface{})
+		if ok && rootNodeMap["id"] == id { //TODO: parse ID field from schema
+			return record
+		}
+	}
+	return nil
+}
+
+// removeRecords returns the records whose id is not in ids
+func (v *Validator) removeRecords(records []map[string]interface{}, ids []string) (remaining []map[string]interface{}) {
+	remaining = make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		rootNodeMap, _ := record[v.RootNode].(map[string]interface{})
+		id, _ := rootNodeMap["id"].(string) //TODO: parse ID field from schema
+		if !slices.Contains(ids, id) {
+			remaining = append(remaining, record)
+		}
+	}
+	return
+}
+
+// parseErrorsOf separates the records a store could not parse from the errors that abort the validation
+func parseErrorsOf(err error) (record.ParseErrors, error) {
+	if parseErrors, ok := record.AsParseErrors(err); ok {
+		return parseErrors, nil
+	}
+	return nil, err
+}
+
 func (v *Validator) getESDocument(id string, esDocuments []map[string]interface{}) (string, error) {
 	for _, document := range esDocuments {
 		if document[v.RootNode] != nil {
@@ -61,25 +100,55 @@ func (v *Validator) getESDocument(id string, esDocuments []map[string]interface{
 	return "", nil
 }
 
-func (v *Validator) validateFullChunkSync(chunk []string) (allIdDiffs validation.MismatchedRecords, err error) {
+func (v *Validator) validateFullChunkSync(chunk []string) (allIdDiffs validation.MismatchedRecords, categorized CategorizedMismatches, err error) {
 	allIdDiffs = make(validation.MismatchedRecords)
+	categorized = make(CategorizedMismatches)
 	//retrieve records from db and es
-	esDocuments, err := v.ESClient.GetDocumentsByIDs(chunk)
+	esDocuments, err := v.IndexStore.GetDocumentsByIDs(chunk)
+	esParseErrors, err := parseErrorsOf(err)
 	if err != nil {
-		return allIdDiffs, goErrors.Wrap(err, 0)
+		return allIdDiffs, categorized, goErrors.Wrap(err, 0)
 	}
 	if esDocuments == nil {
 		esDocuments = make([]map[string]interface{}, 0)
 	}
 
-	dbRecords, err := v.DBClient.GetRowsByIDs(chunk)
+	dbRecords, err := v.SourceStore.GetRowsByIDs(chunk)
+	dbParseErrors, err := parseErrorsOf(err)
 	if err != nil {
-		return allIdDiffs, goErrors.Wrap(err, 0)
+		return allIdDiffs, categorized, goErrors.Wrap(err, 0)
 	}
 	if dbRecords == nil {
 		dbRecords = make([]map[string]interface{}, 0)
 	}
 
+	//records that cannot be parsed are parse error mismatches, the rest of the chunk is compared as usual
+	parseErrors := append(dbParseErrors, esParseErrors...)
+	if len(parseErrors) > 0 {
+		for _, parseError := range parseErrors {
+			if _, hasKey := allIdDiffs[parseError.ID]; !hasKey {
+				esDocumentString, err := v.getESDocument(parseError.ID, esDocuments)
+				if err != nil {
+					return allIdDiffs, categorized, goErrors.Wrap(err, 0)
+				}
+				dbRecordString, err := v.getDBRecord(parseError.ID, dbRecords)
+				if err != nil {
+					return allIdDiffs, categorized, goErrors.Wrap(err, 0)
+				}
+				allIdDiffs[parseError.ID] = &validation.ContentDiff{
+					ESDocument: esDocumentString,
+					DBRecord:   dbRecordString,
+				}
+			}
+			allIdDiffs[parseError.ID].AddDiff(parseError.Error())
+		}
+
+		unparsedIDs := parseErrors.IDs()
+		chunk = removeIDs(chunk, unparsedIDs)
+		dbRecords = v.removeRecords(dbRecords, unparsedIDs)
+		esDocuments = v.removeRecords(esDocuments, unparsedIDs)
+	}
+
 	deep.MaxDiff = len(chunk) * 100
 	diffs := deep.Equal(dbRecords, esDocuments)
 
@@ -102,11 +171,11 @@ func (v *Validator) validateFullChunkSync(chunk []string) (allIdDiffs validation
 		} else {
 			esDocumentString, err := v.getESDocument(id, esDocuments)
 			if err != nil {
-				return allIdDiffs, goErrors.Wrap(err, 0)
+				return allIdDiffs, categorized, goErrors.Wrap(err, 0)
 			}
 			dbRecordString, err := v.getDBRecord(id, dbRecords)
 			if err != nil {
-				return allIdDiffs, goErrors.Wrap(err, 0)
+				return allIdDiffs, categorized, goErrors.Wrap(err, 0)
 			}
 
 			allIdDiffs[id] = &validation.ContentDiff{
@@ -117,13 +186,33 @@ func (v *Validator) validateFullChunkSync(chunk []string) (allIdDiffs validation
 		}
 	}
 
+	for id, diff := range allIdDiffs {
+		categorized[id] = v.classifyContentMismatch(v.findRecord(id, dbRecords), v.findRecord(id, esDocuments), diff.Diffs)
+	}
+	for _, parseError := range parseErrors {
+		categorized[parseError.ID] = CategoryParseError
+	}
+
 	return
 }
 
 func (v *Validator) validateFullChunkAsync(chunk []string, allIdDiffs chan validation.MismatchedRecords, errorsChan chan error, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	diffs, err := v.validateFullChunkSync(chunk)
+	if v.HashFirstPass {
+		var err error
+		chunk, err = v.hashMismatchedIDs(chunk)
+		if err != nil {
+			errorsChan <- err
+			return
+		}
+		if len(chunk) == 0 {
+			allIdDiffs <- make(validation.MismatchedRecords)
+			return
+		}
+	}
+
+	diffs, _, err := v.validateFullChunkSync(chunk)
 	if err != nil {
 		errorsChan <- err
 		return
@@ -133,31 +222,36 @@ func (v *Validator) validateFullChunkAsync(chunk []string, allIdDiffs chan valid
 }
 
 func (v *Validator) ValidateContent() (result ValidateContentResult, err error) {
-	v.Log.Debug("starting content validation", "num ids", len(v.dbIds), "max threads", v.ContentMaxThreads, "chunk size", v.ContentChunkSize)
+	//the ids are the sample when SampleMethod is set
+	ids := v.dbIds
+	atomic.StoreInt64(&v.hashMismatches, 0)
+
+	v.Log.Debug("starting content validation", "num ids", len(ids), "max threads", v.ContentMaxThreads, "chunk size", v.ContentChunkSize)
 
 	chunkSize := v.ContentChunkSize
-	var numChunks = int(math.Ceil(float64(len(v.dbIds)) / float64(chunkSize)))
+	var numChunks = int(math.Ceil(float64(len(ids)) / float64(chunkSize)))
 
 	allIdDiffs := make(chan validation.MismatchedRecords, numChunks)
-	errorsChan := make(chan error, len(v.dbIds))
+	errorsChan := make(chan error, len(ids))
 	numThreads := 0
 	wg := new(sync.WaitGroup)
+	var mismatchedIds []string
 
 	for j := 0; j < numChunks; j++ {
-		//prevent the diffs from growing too large and eating up all the memory
-		if len(allIdDiffs) > 50 || len(errorsChan) > 50 {
+		//stop when the chunks keep failing
+		if len(errorsChan) > 50 {
 			break
 		}
 
 		//determine which chunk of systems to validate
 		start := j * chunkSize
 		var end int
-		if j == numChunks-1 && len(v.dbIds)%chunkSize > 0 {
-			end = start + (len(v.dbIds) % chunkSize)
+		if j == numChunks-1 && len(ids)%chunkSize > 0 {
+			end = start + (len(ids) % chunkSize)
 		} else {
 			end = start + chunkSize
 		}
-		chunk := v.dbIds[start:end]
+		chunk := ids[start:end]
 
 		//validate chunks in parallel
 		v.Log.Debug("starting content validation thread", "thread number", numThreads, "chunk start", start, "chunk end", end)
@@ -170,9 +264,13 @@ func (v *Validator) ValidateContent() (result ValidateContentResult, err error)
 			v.Log.Debug("waiting for content validation chunk to complete")
 			wg.Wait()
 			numThreads = 0
+
+			//only the ids are kept so the diffs do not eat up all the memory, the double check compares them again
+			mismatchedIds = drainMismatchedIDs(allIdDiffs, mismatchedIds)
 		}
 	}
 
+	wg.Wait()
 	close(allIdDiffs)
 	close(errorsChan)
 
@@ -189,48 +287,81 @@ func (v *Validator) ValidateContent() (result ValidateContentResult, err error)
 	}
 
 	//double check mismatched records to account for lag
-	mismatchedIds := make([]string, 0, len(allIdDiffs))
-	for currentDiff := range allIdDiffs {
-		for id := range currentDiff {
-			mismatchedIds = append(mismatchedIds, id)
-		}
-	}
+	mismatchedIds = drainMismatchedIDs(allIdDiffs, mismatchedIds)
+	sort.Strings(mismatchedIds)
 
+	//every mismatched id is kept for the remediation list, the diffs of at most 50 records are logged
+	doubleCheckedIds := make([]string, 0, len(mismatchedIds))
 	var doubleCheckedDiffs validation.MismatchedRecords
 	if len(mismatchedIds) > 0 {
-		doubleCheckedDiffs, err = v.validateFullChunkSync(mismatchedIds)
+		doubleCheckedDiffs = make(validation.MismatchedRecords)
+		result.Categorized = make(CategorizedMismatches)
+	}
+	for start := 0; start < len(mismatchedIds); start += chunkSize {
+		end := start + chunkSize
+		if end > len(mismatchedIds) {
+			end = len(mismatchedIds)
+		}
+
+		diffs, categorized, err := v.validateFullChunkSync(mismatchedIds[start:end])
 		if err != nil {
-			return
+			return result, err
+		}
+		result.Categorized.merge(categorized)
+
+		chunkIds := make([]string, 0, len(diffs))
+		for id := range diffs {
+			chunkIds = append(chunkIds, id)
+		}
+		sort.Strings(chunkIds)
+
+		chunkIds, inFlightIds, err := v.splitInFlight(chunkIds)
+		if err != nil {
+			return result, err
 		}
+		for _, id := range inFlightIds {
+			result.Categorized[id] = CategoryInFlight
+		}
+		result.InFlightIDs = append(result.InFlightIDs, inFlightIds...)
 
-		mismatchedIds = []string{}
-		for id := range doubleCheckedDiffs {
-			mismatchedIds = append(mismatchedIds, id)
+		for _, id := range chunkIds {
+			doubleCheckedIds = append(doubleCheckedIds, id)
+			if len(doubleCheckedDiffs) < 50 {
+				doubleCheckedDiffs[id] = diffs[id]
+			}
 		}
 	}
+	mismatchedIds = doubleCheckedIds
 
 	//determine if the data is valid within the threshold
-	result.MismatchCount = len(doubleCheckedDiffs)
-	result.MismatchRatio = float64(result.MismatchCount) / math.Max(float64(len(v.dbIds)), 1)
+	result.MismatchCount = len(mismatchedIds)
+	result.MismatchRatio = float64(result.MismatchCount) / math.Max(float64(len(ids)), 1)
 	result.ContentIsValid = (result.MismatchRatio * 100) <= float64(v.InvalidThresholdPercentage)
+	if v.SampleMethod != "" {
+		result.Sample = v.sampleEstimate(result.MismatchCount, len(ids), v.samplePopulationSize)
+		result.ContentIsValid = (result.Sample.EstimatedMismatchRatio * 100) <= float64(v.InvalidThresholdPercentage)
+	}
 	result.MismatchedIDs = mismatchedIds
-	result.TotalRecordsValidated = len(v.dbIds)
-	result.MismatchedRecords = make(validation.MismatchedRecords)
-
-	//log at most 50 invalid systems
-	if result.MismatchCount > 50 {
-		counter := 1
-		for id, diff := range doubleCheckedDiffs {
-			if counter > 50 {
-				break
-			}
+	result.TotalRecordsValidated = len(ids)
+	result.HashMismatchCount = int(atomic.LoadInt64(&v.hashMismatches))
+	result.MismatchedRecords = doubleCheckedDiffs
+
+	return
+}
 
-			result.MismatchedRecords[id] = diff
-			counter += 1
+// drainMismatchedIDs appends the ids of the diffs received so far to mismatchedIds
+func drainMismatchedIDs(allIdDiffs chan validation.MismatchedRecords, mismatchedIds []string) []string {
+	for {
+		select {
+		case currentDiff, ok := <-allIdDiffs:
+			if !ok {
+				return mismatchedIds
+			}
+			for id := range currentDiff {
+				mismatchedIds = append(mismatchedIds, id)
+			}
+		default:
+			return mismatchedIds
 		}
-	} else {
-		result.MismatchedRecords = doubleCheckedDiffs
 	}
-
-	return
 }
@@ -6,39 +6,110 @@ import (
 	"github.com/go-errors/errors"
 )
 
+const (
+	CountExact      = "exact"      //count(*)
+	CountReltuples  = "reltuples"  //the planner estimate in pg_class, updated by VACUUM and ANALYZE
+	CountLiveTuples = "n_live_tup" //the statistics collector estimate in pg_stat_user_tables
+)
+
+// countMismatchThreshold is the ratio of the counts above which the count is invalid
+const countMismatchThreshold = 0.2
+
+// EstimatingSourceStore is a SourceStore that can estimate the number of rows without counting them
+type EstimatingSourceStore interface {
+	SourceStore
+	EstimateTableCount(method string) (count int, err error) //a negative count means there is no estimate
+}
+
 type ValidateCountResult struct {
 	CountIsValid  bool    `json:"countIsValid,omitempty"`
 	ESCount       int     `json:"esCount,omitempty"`
 	DBCount       int     `json:"dbCount,omitempty"`
 	MismatchCount int     `json:"MismatchCount,omitempty"`
 	MismatchRatio float64 `json:"MismatchRatio,omitempty"`
+	CountMethod   string  `json:"countMethod,omitempty"` //how DBCount was determined when estimates are enabled
 }
 
 func (v *Validator) ValidateCount() (result ValidateCountResult, err error) {
 	v.Log.Debug("Starting count validation")
 
-	dbCount, err := v.DBClient.CountTable()
+	esCount, err := v.IndexStore.CountIndex()
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+	result.ESCount = esCount
+
+	if v.CountEstimateMethod != "" {
+		estimated, err := v.estimateCount(esCount)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+		if estimated.CountMethod != "" {
+			v.SetDBCount(estimated.DBCount)
+			return estimated, nil
+		}
+	}
+
+	dbCount, err := v.SourceStore.CountTable()
 	if err != nil {
 		return result, errors.Wrap(err, 0)
 	}
 	result.DBCount = dbCount
+	if v.CountEstimateMethod != "" {
+		result.CountMethod = CountExact
+	}
 	v.SetDBCount(dbCount)
+	v.compareCounts(&result)
 
-	esCount, err := v.ESClient.CountIndex()
+	return
+}
+
+// estimateCount compares the estimated number of rows with esCount. The result has no CountMethod when there is
+// no estimate or when the estimated mismatch ratio is within CountEstimateMargin of the threshold, and the
+// rows have to be counted.
+func (v *Validator) estimateCount(esCount int) (result ValidateCountResult, err error) {
+	sourceStore, ok := v.SourceStore.(EstimatingSourceStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("count estimates are not supported by the source store"), 0)
+	}
+
+	estimate, err := sourceStore.EstimateTableCount(v.CountEstimateMethod)
 	if err != nil {
 		return result, errors.Wrap(err, 0)
 	}
+	//before Postgres 14 reltuples is 0 rather than -1 for a table that has never been analyzed, so an estimate of 0
+	//is not trusted either, counting an empty table is cheap
+	if estimate <= 0 {
+		v.Log.Debug("No count estimate, counting the rows", "method", v.CountEstimateMethod)
+		return ValidateCountResult{}, nil
+	}
+
 	result.ESCount = esCount
+	result.DBCount = estimate
+	v.compareCounts(&result)
 
-	diff := math.Abs(float64(dbCount - esCount))
+	margin := v.CountEstimateMargin
+	if margin <= 0 {
+		margin = 0.05
+	}
+	if math.Abs(result.MismatchRatio-countMismatchThreshold) <= margin {
+		v.Log.Debug("Count estimate is close to the threshold, counting the rows",
+			"method", v.CountEstimateMethod, "estimate", estimate, "mismatchRatio", result.MismatchRatio)
+		return ValidateCountResult{}, nil
+	}
+
+	result.CountMethod = v.CountEstimateMethod
+	return result, nil
+}
+
+func (v *Validator) compareCounts(result *ValidateCountResult) {
+	diff := math.Abs(float64(result.DBCount - result.ESCount))
 	result.MismatchCount = int(diff)
-	result.MismatchRatio = math.Round(diff/math.Max(math.Max(float64(dbCount), float64(esCount)), 1)*100) / 100
+	result.MismatchRatio = math.Round(diff/math.Max(math.Max(float64(result.DBCount), float64(result.ESCount)), 1)*100) / 100
 
-	if result.MismatchRatio > 0.2 {
+	if result.MismatchRatio > countMismatchThreshold {
 		result.CountIsValid = false
 	} else {
 		result.CountIsValid = true
 	}
-
-	return
 }
@@ -0,0 +1,72 @@
+package validator_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Elasticsearch credentials", func() {
+	var authHeaders []string
+
+	BeforeEach(func() {
+		authHeaders = []string{}
+		httpmock.Activate()
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es:9200/mockindex/_count",
+			func(req *http.Request) (*http.Response, error) {
+				authHeaders = append(authHeaders, req.Header.Get("Authorization"))
+				return httpmock.NewStringResponse(200, `{"count": 1}`), nil
+			})
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should reload the API key when the mounted file changes", func() {
+		apiKeyFile := filepath.Join(GinkgoT().TempDir(), "api-key")
+		Expect(os.WriteFile(apiKeyFile, []byte("first-key\n"), 0600)).To(Succeed())
+
+		esClient, err := elasticsearch.NewESClient(elasticsearch.ESParams{
+			Url:        "http://mock-es:9200",
+			APIKeyFile: apiKeyFile,
+			Index:      "mockindex",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = esClient.CountIndex()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.WriteFile(apiKeyFile, []byte("second-key\n"), 0600)).To(Succeed())
+		later := time.Now().Add(time.Minute)
+		Expect(os.Chtimes(apiKeyFile, later, later)).To(Succeed())
+
+		_, err = esClient.CountIndex()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(authHeaders).To(Equal([]string{"ApiKey first-key", "ApiKey second-key"}))
+	})
+
+	It("should use basic auth when no API key is configured", func() {
+		esClient, err := elasticsearch.NewESClient(elasticsearch.ESParams{
+			Url:      "http://mock-es:9200",
+			Username: "mock",
+			Password: "mockpassword",
+			Index:    "mockindex",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = esClient.CountIndex()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(authHeaders).To(Equal([]string{"Basic bW9jazptb2NrcGFzc3dvcmQ="}))
+	})
+})
@@ -0,0 +1,192 @@
+package validator_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func es8Responder(body string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		res := httpmock.NewStringResponse(200, body)
+		res.Header.Set("Content-Type", "application/json")
+		res.Header.Set("X-Elastic-Product", "Elasticsearch")
+		return res, nil
+	}
+}
+
+var _ = Describe("Elasticsearch 8 index", func() {
+	var validator Validator
+	var dbMock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		testEnv := test.BeforeEach()
+		validator = testEnv.Validator
+		dbMock = testEnv.DBMock
+
+		esClient, err := elasticsearch.NewES8Client(elasticsearch.ESParams{
+			Url:       "http://mock-es8:9200",
+			APIKey:    "bW9jazptb2Nr",
+			Index:     "mockindex",
+			RootNode:  "host",
+			Transport: httpmock.DefaultTransport,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		validator.IndexStore = esClient
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should validate count", func() {
+		dbMock.ExpectQuery("SELECT count(*) from hosts").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("1"))
+
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_count",
+			es8Responder(`{"count": 1, "_shards": {"total": 1, "successful": 1, "skipped": 0, "failed": 0}}`))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(result.ESCount).To(Equal(1))
+
+		info := httpmock.GetCallCountInfo()
+		Expect(info["POST http://mock-es8:9200/mockindex/_count"]).To(Equal(1))
+	})
+
+	It("should read the ids of an id range", func() {
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_search?scroll=1m",
+			es8Responder(test.LoadTestDataFile("elasticsearch/id/one.hit.response")))
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/_search/scroll",
+			es8Responder(test.LoadTestDataFile("elasticsearch/id/empty.scroll.response")))
+
+		indexStore, ok := validator.IndexStore.(KeyRangeIndexStore)
+		Expect(ok).To(BeTrue())
+		ids, err := indexStore.GetIDsByIDRange("1000", "2000", validator.Now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1234"}))
+	})
+
+	It("should read the stored content hashes", func() {
+		esClient, err := elasticsearch.NewES8Client(elasticsearch.ESParams{
+			Url:       "http://mock-es8:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			HashField: "host.content_hash",
+			Transport: httpmock.DefaultTransport,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_search",
+			es8Responder(`{"hits": {"hits": [{"_id": "1234", "_source": {"host": {"content_hash": "abc"}}}]}}`))
+
+		var indexStore HashIndexStore = esClient
+		hashes, err := indexStore.GetDocumentHashesByIDs([]string{"1234"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hashes).To(Equal(map[string]string{"1234": "abc"}))
+	})
+
+	It("should read the stored content hashes of an id prefix", func() {
+		esClient, err := elasticsearch.NewES8Client(elasticsearch.ESParams{
+			Url:       "http://mock-es8:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			HashField: "host.content_hash",
+			Transport: httpmock.DefaultTransport,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_search",
+			es8Responder(`{"hits": {"hits": [{"_id": "1234", "_source": {"host": {"content_hash": "abc"}}}]}}`))
+
+		var indexStore MerkleIndexStore = esClient
+		hashes, err := indexStore.GetDocumentHashesByIDPrefix("12", 10, validator.Now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hashes).To(Equal(map[string]string{"1234": "abc"}))
+	})
+
+	It("should detect the cluster version", func() {
+		httpmock.RegisterResponder(
+			"GET",
+			"http://mock-es8:9200/",
+			es8Responder(`{"name": "es", "version": {"number": "8.9.0"}}`))
+
+		major, err := elasticsearch.DetectMajorVersion(elasticsearch.ESParams{Url: "http://mock-es8:9200"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(major).To(Equal(8))
+	})
+
+	It("should count the documents of each window", func() {
+		esClient, err := elasticsearch.NewES8Client(elasticsearch.ESParams{
+			Url:       "http://mock-es8:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			Transport: httpmock.DefaultTransport,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_search",
+			es8Responder(`{"aggregations": {"windows": {"buckets": [{"key": 1685613600000, "doc_count": 2}]}}}`))
+
+		var indexStore WindowedCountIndexStore = esClient
+		start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+		counts, err := indexStore.CountIndexByWindow("modified_on", "hour", start, start.Add(2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(counts).To(Equal(map[time.Time]int{start: 2}))
+	})
+
+	It("should count the documents of each tenant", func() {
+		esClient, err := elasticsearch.NewES8Client(elasticsearch.ESParams{
+			Url:       "http://mock-es8:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			Transport: httpmock.DefaultTransport,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_search",
+			es8Responder(`{"aggregations": {"tenants": {"buckets": [{"key": {"tenant": "12345"}, "doc_count": 2}]}}}`))
+
+		var indexStore TenantIndexStore = esClient
+		counts, err := indexStore.CountIndexByTenant("org_id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(counts).To(Equal(map[string]int{"12345": 2}))
+	})
+
+	It("should read the tenant of each document", func() {
+		esClient, err := elasticsearch.NewES8Client(elasticsearch.ESParams{
+			Url:       "http://mock-es8:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			Transport: httpmock.DefaultTransport,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es8:9200/mockindex/_search",
+			es8Responder(`{"hits": {"hits": [{"_id": "1234", "_source": {"host": {"org_id": "12345"}}}]}}`))
+
+		var indexStore TenantIndexStore = esClient
+		tenants, err := indexStore.GetDocumentTenantsByIDs("org_id", []string{"1234"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tenants).To(Equal(map[string]string{"1234": "12345"}))
+	})
+})
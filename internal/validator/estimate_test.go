@@ -0,0 +1,92 @@
+package validator_test
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Count estimates", func() {
+	var validator Validator
+	var dbMock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		testEnv := test.BeforeEach()
+		validator = testEnv.Validator
+		dbMock = testEnv.DBMock
+		validator.CountEstimateMethod = CountReltuples
+	})
+
+	AfterEach(func() {
+		Expect(dbMock.ExpectationsWereMet()).To(Succeed())
+		httpmock.DeactivateAndReset()
+	})
+
+	esCount := func(count string) {
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es:9200/mockindex/_count",
+			httpmock.NewStringResponder(200, `{"count": `+count+`}`))
+	}
+
+	It("should use the estimate when it is far from the threshold", func() {
+		esCount("1000")
+		dbMock.ExpectQuery("SELECT reltuples::bigint FROM pg_class WHERE oid = 'hosts'::regclass").
+			WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow("990"))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ValidateCountResult{
+			CountIsValid:  true,
+			ESCount:       1000,
+			DBCount:       990,
+			MismatchCount: 10,
+			MismatchRatio: 0.01,
+			CountMethod:   CountReltuples,
+		}))
+	})
+
+	It("should count the rows when the estimate is close to the threshold", func() {
+		esCount("1000")
+		dbMock.ExpectQuery("SELECT reltuples::bigint FROM pg_class WHERE oid = 'hosts'::regclass").
+			WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow("820"))
+		dbMock.ExpectQuery("SELECT count(*) from hosts").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("700"))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.DBCount).To(Equal(700))
+		Expect(result.CountIsValid).To(BeFalse())
+		Expect(result.CountMethod).To(Equal(CountExact))
+	})
+
+	It("should count the rows when the table has not been analyzed", func() {
+		esCount("5")
+		dbMock.ExpectQuery("SELECT reltuples::bigint FROM pg_class WHERE oid = 'hosts'::regclass").
+			WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow("-1"))
+		dbMock.ExpectQuery("SELECT count(*) from hosts").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("5"))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(result.CountMethod).To(Equal(CountExact))
+	})
+
+	It("should count the rows when reltuples is zero", func() {
+		esCount("5")
+		dbMock.ExpectQuery("SELECT reltuples::bigint FROM pg_class WHERE oid = 'hosts'::regclass").
+			WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow("0"))
+		dbMock.ExpectQuery("SELECT count(*) from hosts").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("5"))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.DBCount).To(Equal(5))
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(result.CountMethod).To(Equal(CountExact))
+	})
+})
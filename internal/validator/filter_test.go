@@ -0,0 +1,106 @@
+package validator_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/database"
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Row filter", func() {
+	var validator Validator
+	var dbMock sqlmock.Sqlmock
+	var countQuery map[string]interface{}
+
+	BeforeEach(func() {
+		testEnv := test.BeforeEach()
+		validator = testEnv.Validator
+		dbMock = testEnv.DBMock
+
+		rowFilter, err := filter.Parse(
+			`[{"field": "deleted", "operator": "ne", "value": true}, {"field": "org_id", "operator": "in", "value": ["1", "o'2"]}]`)
+		Expect(err).ToNot(HaveOccurred())
+
+		schemaParser := avro.SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/full")}
+		parsedSchema, err := schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+
+		validator.SourceStore = database.NewTestDBClient(sqlx.NewDb(testEnv.MockDB, "sqlmock"), database.DBParams{
+			Table:            "hosts",
+			Filter:           rowFilter,
+			ParsedAvroSchema: parsedSchema,
+		})
+		validator.IndexStore, err = elasticsearch.NewESClient(elasticsearch.ESParams{
+			Url:              "http://mock-es:9200",
+			Index:            "mockindex",
+			RootNode:         parsedSchema.RootNode,
+			Filter:           rowFilter,
+			ParsedAvroSchema: parsedSchema,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		countQuery = nil
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-es:9200/mockindex/_count",
+			func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.Unmarshal(body, &countQuery)).To(Succeed())
+				return httpmock.NewStringResponse(200, `{"count": 1}`), nil
+			})
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should apply the filter to the database and index counts", func() {
+		dbMock.ExpectQuery("SELECT count(*) from hosts WHERE (deleted IS DISTINCT FROM true AND org_id IN ('1', 'o''2'))").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("1"))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(dbMock.ExpectationsWereMet()).To(Succeed())
+
+		expected := `{"query": {"bool": {"filter": [
+			{"bool": {"must_not": [{"term": {"host.deleted": true}}]}},
+			{"terms": {"host.org_id": ["1", "o'2"]}}
+		]}}}`
+		var expectedQuery map[string]interface{}
+		Expect(json.Unmarshal([]byte(expected), &expectedQuery)).To(Succeed())
+		Expect(countQuery).To(Equal(expectedQuery))
+	})
+
+	It("should combine the filter with the conditions of the id queries", func() {
+		dbMock.ExpectQuery("SELECT id FROM hosts WHERE id in ('1','2') AND (deleted IS DISTINCT FROM true AND org_id IN ('1', 'o''2'))").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+
+		ids, err := validator.SourceStore.GetIDsByIDList([]string{"1", "2"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1"}))
+	})
+
+	It("should reject invalid conditions", func() {
+		_, err := filter.Parse(`[{"field": "deleted; DROP TABLE hosts", "operator": "eq", "value": true}]`)
+		Expect(err).To(HaveOccurred())
+
+		_, err = filter.Parse(`[{"field": "deleted", "operator": "like", "value": "x"}]`)
+		Expect(err).To(HaveOccurred())
+
+		_, err = filter.Parse(`[{"field": "org_id", "operator": "in", "value": "1"}]`)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"sync/atomic"
+
+	"github.com/go-errors/errors"
+)
+
+// HashSourceStore is a SourceStore that can compute a content hash per row
+type HashSourceStore interface {
+	SourceStore
+	GetRowHashesByIDs(ids []string) (hashes map[string]string, err error)
+}
+
+// HashIndexStore is an IndexStore that can retrieve or compute a content hash per document
+type HashIndexStore interface {
+	IndexStore
+	GetDocumentHashesByIDs(ids []string) (hashes map[string]string, err error)
+}
+
+// hashMismatchedIDs compares the content hashes of a chunk and returns the ids whose hashes differ,
+// only these ids need their full row and document compared
+func (v *Validator) hashMismatchedIDs(chunk []string) (ids []string, err error) {
+	sourceStore, ok := v.SourceStore.(HashSourceStore)
+	if !ok {
+		return ids, errors.Wrap(errors.New("hash comparison is not supported by the source store"), 0)
+	}
+	indexStore, ok := v.IndexStore.(HashIndexStore)
+	if !ok {
+		return ids, errors.Wrap(errors.New("hash comparison is not supported by the index backend"), 0)
+	}
+
+	dbHashes, err := sourceStore.GetRowHashesByIDs(chunk)
+	if err != nil {
+		return ids, errors.Wrap(err, 0)
+	}
+	esHashes, err := indexStore.GetDocumentHashesByIDs(chunk)
+	if err != nil {
+		return ids, errors.Wrap(err, 0)
+	}
+
+	for _, id := range chunk {
+		if dbHashes[id] != esHashes[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	atomic.AddInt64(&v.hashMismatches, int64(len(ids)))
+	return
+}
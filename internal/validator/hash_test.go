@@ -0,0 +1,78 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Hash first pass content validation", func() {
+	var validator Validator
+	var now time.Time
+	var ids []string
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			PeriodMin:                  100,
+			Now:                        now,
+			RootNode:                   "host",
+			ContentChunkSize:           10,
+			ContentMaxThreads:          2,
+			InvalidThresholdPercentage: 5,
+			HashFirstPass:              true,
+		}
+
+		var records []map[string]interface{}
+		ids = nil
+		for i := 0; i < 50; i++ {
+			id := fmt.Sprintf("%03d", i)
+			ids = append(ids, id)
+			records = append(records, test.HostRecord(id, "name", now.Add(-time.Hour)))
+		}
+		validator.SourceStore, validator.IndexStore = test.HostStores(records...)
+	})
+
+	It("should not compare full records when every hash matches", func() {
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.Content.AmountValidated).To(Equal(50))
+		Expect(response.Details.Content.InconsistencyAbsolute).To(Equal(0))
+		Expect(validator.SourceStore.(*test.InMemoryStore).RecordsRead).To(BeZero())
+		Expect(validator.IndexStore.(*test.InMemoryStore).RecordsRead).To(BeZero())
+	})
+
+	It("should only compare the full records whose hashes differ", func() {
+		for _, record := range validator.IndexStore.(*test.InMemoryStore).Records[:5] {
+			record["host"].(map[string]interface{})["display_name"] = "different"
+		}
+
+		validator.SetDBIDs(ids)
+		result, err := validator.ValidateContent()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.HashMismatchCount).To(Equal(5))
+		Expect(result.MismatchCount).To(Equal(5))
+		Expect(result.MismatchedIDs).To(ConsistOf("000", "001", "002", "003", "004"))
+		Expect(result.ContentIsValid).To(BeFalse())
+		//the 5 mismatched records are read again by the double check
+		Expect(validator.SourceStore.(*test.InMemoryStore).RecordsRead).To(Equal(int64(10)))
+		Expect(validator.IndexStore.(*test.InMemoryStore).RecordsRead).To(Equal(int64(10)))
+	})
+
+	It("should fail when the stores cannot compute hashes", func() {
+		validator.SourceStore = &hashlessStore{validator.SourceStore}
+
+		_, err := validator.Validate()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+type hashlessStore struct {
+	SourceStore
+}
@@ -128,6 +128,7 @@ var _ = Describe("ID validation", func() {
 				MismatchCount:           1,
 				MismatchRatio:           1,
 				IDsAreValid:             false,
+				Categorized:             CategorizedMismatches{"1234": CategoryMissingInIndex},
 			}))
 
 			info := httpmock.GetCallCountInfo()
@@ -168,6 +169,7 @@ var _ = Describe("ID validation", func() {
 				MismatchCount:           1,
 				MismatchRatio:           1,
 				IDsAreValid:             false,
+				Categorized:             CategorizedMismatches{"1234": CategoryOrphanedInIndex},
 			}))
 
 			info := httpmock.GetCallCountInfo()
@@ -211,6 +213,7 @@ var _ = Describe("ID validation", func() {
 				MismatchCount:           2,
 				MismatchRatio:           1,
 				IDsAreValid:             false,
+				Categorized:             CategorizedMismatches{"5678": CategoryMissingInIndex, "1234": CategoryOrphanedInIndex},
 			}))
 
 			info := httpmock.GetCallCountInfo()
@@ -254,6 +257,12 @@ var _ = Describe("ID validation", func() {
 				MismatchCount:           4,
 				MismatchRatio:           0.8,
 				IDsAreValid:             false,
+				Categorized: CategorizedMismatches{
+					"db.only.1": CategoryMissingInIndex,
+					"db.only.2": CategoryMissingInIndex,
+					"es.only.1": CategoryOrphanedInIndex,
+					"es.only.2": CategoryOrphanedInIndex,
+				},
 			}))
 
 			info := httpmock.GetCallCountInfo()
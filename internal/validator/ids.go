@@ -8,13 +8,15 @@ import (
 )
 
 type ValidateIDsResult struct {
-	InDBOnly                []string `json:"inDBOnly,omitempty"`
-	InESOnly                []string `json:"inESOnly,omitempty"`
-	TotalDBRecordsRetrieved int      `json:"totalDBRecordsRetrieved,omitempty"`
-	TotalESRecordsRetrieved int      `json:"totalESRecordsRetrieved,omitempty"`
-	MismatchCount           int      `json:"mismatchCount,omitempty"`
-	MismatchRatio           float64  `json:"mismatchRatio,omitempty"`
-	IDsAreValid             bool     `json:"idsAreValid,omitempty"`
+	InDBOnly                []string              `json:"inDBOnly,omitempty"`
+	InESOnly                []string              `json:"inESOnly,omitempty"`
+	InFlight                []string              `json:"inFlight,omitempty"`
+	TotalDBRecordsRetrieved int                   `json:"totalDBRecordsRetrieved,omitempty"`
+	TotalESRecordsRetrieved int                   `json:"totalESRecordsRetrieved,omitempty"`
+	MismatchCount           int                   `json:"mismatchCount,omitempty"`
+	MismatchRatio           float64               `json:"mismatchRatio,omitempty"`
+	IDsAreValid             bool                  `json:"idsAreValid,omitempty"`
+	Categorized             CategorizedMismatches `json:"-"`
 }
 
 func (v *Validator) validateIdChunk(dbIds []string, esIds []string) (mismatchCount int, inDBOnly []string, inESOnly []string) {
@@ -33,19 +35,27 @@ func (v *Validator) ValidateIDs() (result ValidateIDsResult, err error) {
 	}
 	endTime := v.Now.Add(-time.Duration(v.LagCompSec) * time.Second)
 
-	//validate chunk between startTime and endTime //TODO: can this rely on the presence of a modified_on field?
 	var dbIds []string
-	dbIds, err = v.DBClient.GetIDsByModifiedOn(startTime, endTime)
-	if err != nil {
-		return result, errors.Wrap(err, 0)
-	}
-	v.dbIds = dbIds
-
 	var esIds []string
-	esIds, err = v.ESClient.GetIDsByModifiedOn(startTime, endTime)
-	if err != nil {
-		return result, errors.Wrap(err, 0)
+	if v.SampleMethod != "" {
+		//only the sampled ids are compared and validated
+		dbIds, esIds, err = v.sampledIDs(startTime, endTime)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+	} else {
+		//validate chunk between startTime and endTime //TODO: can this rely on the presence of a modified_on field?
+		dbIds, err = v.SourceStore.GetIDsByModifiedOn(startTime, endTime)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+
+		esIds, err = v.IndexStore.GetIDsByModifiedOn(startTime, endTime)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
 	}
+	v.dbIds = dbIds
 
 	mismatchCount, inDBOnly, inESOnly := v.validateIdChunk(dbIds, esIds)
 
@@ -54,12 +64,12 @@ func (v *Validator) ValidateIDs() (result ValidateIDsResult, err error) {
 	if mismatchCount > 0 {
 		v.Log.Debug("Double checking mismatched IDs", "inDBOnly", inDBOnly, "inESOnly", inESOnly)
 		mismatchedIds := append(inDBOnly, inESOnly...)
-		mismatchedDBIds, err := v.DBClient.GetIDsByIDList(mismatchedIds)
+		mismatchedDBIds, err := v.SourceStore.GetIDsByIDList(mismatchedIds)
 		if err != nil {
 			return result, errors.Wrap(err, 0)
 		}
 
-		mismatchedESIDs, err := v.ESClient.GetIDsByIDList(mismatchedIds)
+		mismatchedESIDs, err := v.IndexStore.GetIDsByIDList(mismatchedIds)
 		if err != nil {
 			return result, errors.Wrap(err, 0)
 		}
@@ -67,6 +77,24 @@ func (v *Validator) ValidateIDs() (result ValidateIDsResult, err error) {
 		mismatchCount, inDBOnly, inESOnly = v.validateIdChunk(mismatchedDBIds, mismatchedESIDs)
 	}
 
+	if v.ConsistencyMode && mismatchCount > 0 {
+		_, inFlight, err := v.splitInFlight(append(append([]string{}, inDBOnly...), inESOnly...))
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+		inDBOnly = removeIDs(inDBOnly, inFlight)
+		inESOnly = removeIDs(inESOnly, inFlight)
+		mismatchCount = len(inDBOnly) + len(inESOnly)
+		result.InFlight = inFlight
+	}
+
+	if mismatchCount > 0 || len(result.InFlight) > 0 {
+		result.Categorized = make(CategorizedMismatches)
+		result.Categorized.add(inDBOnly, CategoryMissingInIndex)
+		result.Categorized.add(inESOnly, CategoryOrphanedInIndex)
+		result.Categorized.add(result.InFlight, CategoryInFlight)
+	}
+
 	inDBOnlyLength := int(math.Min(float64(len(inDBOnly)), 10))
 	result.InDBOnly = inDBOnly[0:inDBOnlyLength]
 
@@ -0,0 +1,206 @@
+package validator
+
+import (
+	"math"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// KeyOrderSourceStore is a SourceStore that can walk the table in id order
+type KeyOrderSourceStore interface {
+	SourceStore
+	GetIDsAfter(afterID string, limit int, modifiedBefore time.Time) (ids []string, err error) //an empty afterID starts at the first id
+}
+
+// KeyRangeIndexStore is an IndexStore that can retrieve the ids in a range
+type KeyRangeIndexStore interface {
+	IndexStore
+	GetIDsByIDRange(afterID string, lastID string, modifiedBefore time.Time) (ids []string, err error) //ids > afterID and <= lastID, empty ids leave the range open
+}
+
+// CheckpointStore persists the progress of the incremental validation between runs
+type CheckpointStore interface {
+	LoadCheckpoint(key string) (checkpoint Checkpoint, err error)
+	SaveCheckpoint(checkpoint Checkpoint) error
+}
+
+// Checkpoint is the position and cumulative stats of the current pass over the table
+type Checkpoint struct {
+	Key                     string     `json:"key"`
+	LastID                  string     `json:"lastId"` //the last id that was validated, empty at the start of a pass
+	PassStartedAt           time.Time  `json:"passStartedAt"`
+	UpdatedAt               time.Time  `json:"updatedAt"`
+	RecordsValidated        int        `json:"recordsValidated"`
+	MissingInIndex          int        `json:"missingInIndex"`
+	OrphanedInIndex         int        `json:"orphanedInIndex"`
+	ContentMismatches       int        `json:"contentMismatches"`
+	Passes                  int        `json:"passes"`                            //the number of completed passes
+	FullCoverageCompletedAt *time.Time `json:"fullCoverageCompletedAt,omitempty"` //when the last pass completed
+}
+
+type ValidateIncrementalResult struct {
+	Checkpoint       Checkpoint            `json:"checkpoint"`
+	ChunksValidated  int                   `json:"chunksValidated"`
+	RecordsValidated int                   `json:"recordsValidated"`
+	MismatchCount    int                   `json:"mismatchCount"`
+	MismatchRatio    float64               `json:"mismatchRatio"`
+	IsValid          bool                  `json:"isValid"`
+	Categorized      CategorizedMismatches `json:"-"`
+}
+
+// ValidateIncremental validates the next IncrementalChunks chunks of the table in id order, starting after the
+// checkpoint. The checkpoint is saved after each chunk so a restarted run resumes where the last one stopped.
+// Rows and documents modified after the in-flight cutoff are skipped, and content mismatches of rows modified during
+// the run are not added to the cumulative stats, so they are not reported until the next pass.
+func (v *Validator) ValidateIncremental() (result ValidateIncrementalResult, err error) {
+	sourceStore, ok := v.SourceStore.(KeyOrderSourceStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("incremental validation is not supported by the source store"), 0)
+	}
+	indexStore, ok := v.IndexStore.(KeyRangeIndexStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("incremental validation is not supported by the index backend"), 0)
+	}
+	if v.CheckpointStore == nil {
+		return result, errors.Wrap(errors.New("incremental validation requires a checkpoint store"), 0)
+	}
+
+	checkpoint, err := v.CheckpointStore.LoadCheckpoint(v.CheckpointKey)
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+	checkpoint.Key = v.CheckpointKey
+
+	chunkSize := v.IncrementalChunkSize
+	if chunkSize < 1 {
+		chunkSize = 1000
+	}
+
+	cutoff := v.inFlightCutoff()
+	result.Categorized = make(CategorizedMismatches)
+	for v.IncrementalChunks <= 0 || result.ChunksValidated < v.IncrementalChunks {
+		if checkpoint.LastID == "" {
+			checkpoint.PassStartedAt = v.Now
+		}
+
+		dbIds, err := sourceStore.GetIDsAfter(checkpoint.LastID, chunkSize, cutoff)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+
+		//the last chunk of the table also includes the index documents after the last row
+		lastID := ""
+		if len(dbIds) == chunkSize {
+			lastID = dbIds[len(dbIds)-1]
+		}
+
+		esIds, err := indexStore.GetIDsByIDRange(checkpoint.LastID, lastID, cutoff)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+
+		chunkMismatches, err := v.validateIncrementalChunk(dbIds, esIds)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+		for id, category := range chunkMismatches {
+			if category == CategoryInFlight {
+				delete(chunkMismatches, id)
+			}
+		}
+		result.Categorized.merge(chunkMismatches)
+		result.ChunksValidated++
+		result.RecordsValidated += len(dbIds)
+
+		counts := chunkMismatches.Counts()
+		checkpoint.RecordsValidated += len(dbIds)
+		checkpoint.MissingInIndex += counts.MissingInIndex
+		checkpoint.OrphanedInIndex += counts.OrphanedInIndex
+		checkpoint.ContentMismatches += len(chunkMismatches) - counts.MissingInIndex - counts.OrphanedInIndex
+		checkpoint.UpdatedAt = time.Now().UTC()
+
+		if lastID == "" {
+			completedAt := checkpoint.UpdatedAt
+			checkpoint = Checkpoint{
+				Key:                     checkpoint.Key,
+				Passes:                  checkpoint.Passes + 1,
+				FullCoverageCompletedAt: &completedAt,
+				UpdatedAt:               completedAt,
+			}
+			v.Log.Info("Incremental validation completed a pass over the table", "completedAt", completedAt)
+		} else {
+			checkpoint.LastID = lastID
+		}
+
+		err = v.CheckpointStore.SaveCheckpoint(checkpoint)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+
+		if lastID == "" {
+			break
+		}
+	}
+
+	result.Checkpoint = checkpoint
+	result.MismatchCount = len(result.Categorized)
+	result.MismatchRatio = float64(result.MismatchCount) / math.Max(float64(result.RecordsValidated), 1)
+	result.IsValid = (result.MismatchRatio * 100) <= float64(v.InvalidThresholdPercentage)
+
+	return
+}
+
+// validateIncrementalChunk compares the ids of a chunk and the content of the ids that are in both stores
+func (v *Validator) validateIncrementalChunk(dbIds []string, esIds []string) (mismatches CategorizedMismatches, err error) {
+	mismatchCount, inDBOnly, inESOnly := v.validateIdChunk(dbIds, esIds)
+	inBoth := removeIDs(dbIds, inDBOnly)
+
+	//a row modified after the cutoff is only excluded from one store when the other still has its previous copy,
+	//so the mismatched ids are checked again without the cutoff and the content of the ids in both is compared
+	if mismatchCount > 0 {
+		mismatchedIds := append(append([]string{}, inDBOnly...), inESOnly...)
+		mismatchedDBIds, err := v.SourceStore.GetIDsByIDList(mismatchedIds)
+		if err != nil {
+			return mismatches, errors.Wrap(err, 0)
+		}
+		mismatchedESIds, err := v.IndexStore.GetIDsByIDList(mismatchedIds)
+		if err != nil {
+			return mismatches, errors.Wrap(err, 0)
+		}
+		_, inDBOnly, inESOnly = v.validateIdChunk(mismatchedDBIds, mismatchedESIds)
+		inBoth = append(inBoth, removeIDs(mismatchedDBIds, inDBOnly)...)
+	}
+
+	mismatches = make(CategorizedMismatches)
+	mismatches.add(inDBOnly, CategoryMissingInIndex)
+	mismatches.add(inESOnly, CategoryOrphanedInIndex)
+
+	contentChunkSize := v.ContentChunkSize
+	if contentChunkSize < 1 {
+		contentChunkSize = 20
+	}
+	for start := 0; start < len(inBoth); start += contentChunkSize {
+		end := utils.Min(start+contentChunkSize, len(inBoth))
+
+		chunk := inBoth[start:end]
+		if v.HashFirstPass {
+			chunk, err = v.hashMismatchedIDs(chunk)
+			if err != nil {
+				return mismatches, errors.Wrap(err, 0)
+			}
+			if len(chunk) == 0 {
+				continue
+			}
+		}
+
+		_, contentMismatches, err := v.validateFullChunkSync(chunk)
+		if err != nil {
+			return mismatches, errors.Wrap(err, 0)
+		}
+		mismatches.merge(contentMismatches)
+	}
+
+	return
+}
@@ -0,0 +1,113 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Incremental validation", func() {
+	var checkpointStore *test.InMemoryCheckpointStore
+	var sourceStore, indexStore *test.InMemoryStore
+	now := time.Now()
+
+	newValidator := func() Validator {
+		return Validator{
+			Now:                  now,
+			RootNode:             "host",
+			ContentChunkSize:     2,
+			Incremental:          true,
+			IncrementalChunkSize: 4,
+			IncrementalChunks:    2,
+			CheckpointStore:      checkpointStore,
+			CheckpointKey:        "hosts.1",
+			SourceStore:          sourceStore,
+			IndexStore:           indexStore,
+		}
+	}
+
+	BeforeEach(func() {
+		checkpointStore = &test.InMemoryCheckpointStore{}
+		var records []map[string]interface{}
+		for i := 0; i < 10; i++ {
+			records = append(records, test.HostRecord(fmt.Sprintf("%02d", i), "name", now.Add(-time.Hour)))
+		}
+		sourceStore, indexStore = test.HostStores(records...)
+	})
+
+	It("should resume from the checkpoint and report when a full pass completed", func() {
+		validator := newValidator()
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.Incremental.RecordsValidated).To(Equal(8))
+		Expect(response.Details.Incremental.Checkpoint.LastID).To(Equal("07"))
+		Expect(response.Details.Incremental.Checkpoint.FullCoverageCompletedAt).To(BeNil())
+		Expect(checkpointStore.Checkpoints["hosts.1"].LastID).To(Equal("07"))
+
+		validator = newValidator()
+		response, err = validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.Incremental.ChunksValidated).To(Equal(1))
+		Expect(response.Details.Incremental.RecordsValidated).To(Equal(2))
+		Expect(response.Details.Incremental.Checkpoint.LastID).To(Equal(""))
+		Expect(response.Details.Incremental.Checkpoint.Passes).To(Equal(1))
+		Expect(response.Details.Incremental.Checkpoint.FullCoverageCompletedAt).ToNot(BeNil())
+	})
+
+	It("should find missing, orphaned and mismatched records in the chunks", func() {
+		sourceStore.Records = append(sourceStore.Records[:2], sourceStore.Records[3:]...)
+		indexStore.Records = append(indexStore.Records[:5], indexStore.Records[6:]...)
+		indexStore.Records = append(indexStore.Records, test.HostRecord("99", "orphan", now.Add(-time.Hour)))
+		indexStore.Records[0]["host"].(map[string]interface{})["display_name"] = "different"
+
+		validator := newValidator()
+		validator.IncrementalChunks = 0
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Mismatches).To(Equal(CategorizedMismatches{
+			"00": CategoryContentMismatch,
+			"02": CategoryOrphanedInIndex,
+			"05": CategoryMissingInIndex,
+			"99": CategoryOrphanedInIndex,
+		}))
+
+		checkpoint := checkpointStore.Checkpoints["hosts.1"]
+		Expect(checkpoint.Passes).To(Equal(1))
+		Expect(response.Details.Incremental.RecordsValidated).To(Equal(9))
+	})
+
+	It("should not count the records modified after the lag compensation", func() {
+		validator := newValidator()
+		validator.LagCompSec = 60
+		validator.IncrementalChunks = 0
+		sourceStore.Records = append(sourceStore.Records, test.HostRecord("10", "new", now))
+		sourceStore.Records[3]["host"].(map[string]interface{})["modified_on"] = now
+		sourceStore.Records[3]["host"].(map[string]interface{})["display_name"] = "updated"
+		sourceStore.Records[4]["host"].(map[string]interface{})["modified_on"] = now.Add(-30 * time.Second)
+		sourceStore.Records[4]["host"].(map[string]interface{})["display_name"] = "updated"
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Mismatches).To(BeEmpty())
+
+		checkpoint := checkpointStore.Checkpoints["hosts.1"]
+		Expect(checkpoint.Passes).To(Equal(1))
+		Expect(response.Details.Incremental.Checkpoint.ContentMismatches).To(Equal(0))
+		Expect(response.Details.Incremental.RecordsValidated).To(Equal(8))
+	})
+
+	It("should fail without a checkpoint store", func() {
+		validator := newValidator()
+		validator.CheckpointStore = nil
+		_, err := validator.Validate()
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+// IndexComparison compares the validation of the active index with the validation of the refreshing index
+// during a pipeline version switch. It is used to decide if the alias can be swapped to the refreshing index.
+type IndexComparison struct {
+	ActiveIndices        []string                    `json:"activeIndices"`
+	ActiveResult         validation.ValidationResult `json:"activeResult"`
+	ActiveMismatches     int                         `json:"activeMismatches"`
+	RefreshingIndices    []string                    `json:"refreshingIndices"`
+	RefreshingResult     validation.ValidationResult `json:"refreshingResult"`
+	RefreshingMismatches int                         `json:"refreshingMismatches"`
+	SwapRecommended      bool                        `json:"swapRecommended"`
+	Reason               string                      `json:"reason"`
+}
+
+func totalMismatches(response ValidationResponse) int {
+	return response.Details.Counts.InconsistencyAbsolute +
+		response.Details.IDs.InconsistencyAbsolute +
+		response.Details.Content.InconsistencyAbsolute
+}
+
+// CompareIndices recommends swapping the alias when the refreshing index is valid and
+// is at least as consistent with the database as the active index
+func CompareIndices(active ValidationResponse, refreshing ValidationResponse) (comparison IndexComparison) {
+	comparison = IndexComparison{
+		ActiveIndices:        active.Details.Indices,
+		ActiveResult:         active.Result,
+		ActiveMismatches:     totalMismatches(active),
+		RefreshingIndices:    refreshing.Details.Indices,
+		RefreshingResult:     refreshing.Result,
+		RefreshingMismatches: totalMismatches(refreshing),
+	}
+
+	if refreshing.Result != validation.ValidationValid {
+		comparison.Reason = "refreshing index is invalid"
+	} else if active.Result == validation.ValidationValid && comparison.RefreshingMismatches > comparison.ActiveMismatches {
+		comparison.Reason = "refreshing index has more mismatches than the active index"
+	} else {
+		comparison.SwapRecommended = true
+		comparison.Reason = "refreshing index is valid"
+	}
+
+	return
+}
@@ -0,0 +1,76 @@
+package validator_test
+
+import (
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Index aliases", func() {
+	var validator Validator
+
+	BeforeEach(func() {
+		testEnv := test.BeforeEach()
+		validator = testEnv.Validator
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should resolve an alias to the concrete indices", func() {
+		httpmock.RegisterResponder(
+			"GET",
+			"http://mock-es:9200/mockindex/_alias",
+			httpmock.NewStringResponder(200, `{
+				"xjoin.inventory.hosts.2": {"aliases": {"mockindex": {}}},
+				"xjoin.inventory.hosts.1": {"aliases": {"mockindex": {}}}
+			}`))
+
+		indices, err := validator.IndexStore.ResolveIndices()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(indices).To(Equal([]string{"xjoin.inventory.hosts.1", "xjoin.inventory.hosts.2"}))
+	})
+
+	Context("when comparing the active and refreshing index", func() {
+		valid := ValidationResponse{
+			Result: validation.ValidationValid,
+			Details: ResponseDetails{
+				Indices: []string{"xjoin.inventory.hosts.2"},
+			},
+		}
+
+		It("should recommend the swap when the refreshing index is valid", func() {
+			active := ValidationResponse{
+				Result: validation.ValidationInvalid,
+				Details: ResponseDetails{
+					Indices: []string{"xjoin.inventory.hosts.1"},
+					IDs:     validation.IdsDetails{InconsistencyAbsolute: 3},
+				},
+			}
+
+			comparison := CompareIndices(active, valid)
+			Expect(comparison.SwapRecommended).To(BeTrue())
+			Expect(comparison.ActiveMismatches).To(Equal(3))
+			Expect(comparison.RefreshingMismatches).To(Equal(0))
+			Expect(comparison.ActiveIndices).To(Equal([]string{"xjoin.inventory.hosts.1"}))
+			Expect(comparison.RefreshingIndices).To(Equal([]string{"xjoin.inventory.hosts.2"}))
+		})
+
+		It("should not recommend the swap when the refreshing index is invalid", func() {
+			refreshing := ValidationResponse{
+				Result: validation.ValidationInvalid,
+				Details: ResponseDetails{
+					Counts: validation.CountDetails{InconsistencyAbsolute: 10},
+				},
+			}
+
+			comparison := CompareIndices(valid, refreshing)
+			Expect(comparison.SwapRecommended).To(BeFalse())
+			Expect(comparison.Reason).To(Equal("refreshing index is invalid"))
+		})
+	})
+})
@@ -0,0 +1,198 @@
+package validator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/go-errors/errors"
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+)
+
+// MerkleSourceStore is a SourceStore that can aggregate the content hashes of the rows in each range of ids
+type MerkleSourceStore interface {
+	SourceStore
+	GetRowBucketHashes(prefix string, modifiedBefore time.Time) (buckets map[string]record.BucketHash, err error) //keyed by the child prefixes of prefix
+	GetRowHashesByIDPrefix(prefix string, limit int, modifiedBefore time.Time) (hashes map[string]string, err error)
+}
+
+// MerkleIndexStore is an IndexStore that can aggregate the content hashes of the documents in each range of ids
+type MerkleIndexStore interface {
+	IndexStore
+	GetDocumentBucketHashes(prefix string, modifiedBefore time.Time) (buckets map[string]record.BucketHash, err error) //keyed by the child prefixes of prefix
+	GetDocumentHashesByIDPrefix(prefix string, limit int, modifiedBefore time.Time) (hashes map[string]string, err error)
+}
+
+type ValidateMerkleResult struct {
+	BucketsCompared  int                   `json:"bucketsCompared"`
+	BucketsDiffering int                   `json:"bucketsDiffering"`
+	HashesRead       int                   `json:"hashesRead"` //the number of record hashes read from the differing leaf buckets
+	MismatchCount    int                   `json:"mismatchCount"`
+	MismatchRatio    float64               `json:"mismatchRatio"`
+	IsValid          bool                  `json:"isValid"`
+	MismatchedIDs    []string              `json:"mismatchedIDs,omitempty"`
+	Categorized      CategorizedMismatches `json:"-"`
+}
+
+type merkleComparison struct {
+	sourceStore MerkleSourceStore
+	indexStore  MerkleIndexStore
+	leafSize    int
+	cutoff      time.Time //records modified after the cutoff are not compared
+	result      *ValidateMerkleResult
+}
+
+// ValidateMerkle splits the UUIDs into buckets by prefix and compares the count and hash sum of each bucket.
+// Only the buckets that differ are split further, until a bucket is small enough to compare the hash of each record.
+// Records modified after the in-flight cutoff are not compared.
+func (v *Validator) ValidateMerkle() (result ValidateMerkleResult, err error) {
+	sourceStore, ok := v.SourceStore.(MerkleSourceStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("range comparison is not supported by the source store"), 0)
+	}
+	indexStore, ok := v.IndexStore.(MerkleIndexStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("range comparison is not supported by the index backend"), 0)
+	}
+
+	leafSize := v.MerkleLeafSize
+	if leafSize < 1 {
+		leafSize = 1000
+	}
+
+	result.Categorized = make(CategorizedMismatches)
+	comparison := merkleComparison{
+		sourceStore: sourceStore,
+		indexStore:  indexStore,
+		leafSize:    leafSize,
+		cutoff:      v.inFlightCutoff(),
+		result:      &result,
+	}
+	err = v.compareBuckets(comparison, "")
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+
+	for id := range result.Categorized {
+		result.MismatchedIDs = append(result.MismatchedIDs, id)
+	}
+	sort.Strings(result.MismatchedIDs)
+
+	result.MismatchCount = len(result.Categorized)
+	result.MismatchRatio = float64(result.MismatchCount) / math.Max(float64(v.dbCount), 1)
+	result.IsValid = (result.MismatchRatio * 100) <= float64(v.InvalidThresholdPercentage)
+
+	return
+}
+
+func (v *Validator) compareBuckets(comparison merkleComparison, prefix string) error {
+	dbBuckets, err := comparison.sourceStore.GetRowBucketHashes(prefix, comparison.cutoff)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	esBuckets, err := comparison.indexStore.GetDocumentBucketHashes(prefix, comparison.cutoff)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	var keys []string
+	for key := range dbBuckets {
+		keys = append(keys, key)
+	}
+	for key := range esBuckets {
+		if _, ok := dbBuckets[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		comparison.result.BucketsCompared++
+		dbBucket, esBucket := dbBuckets[key], esBuckets[key]
+		if dbBucket == esBucket {
+			continue
+		}
+		comparison.result.BucketsDiffering++
+
+		size := int(math.Max(float64(dbBucket.Count), float64(esBucket.Count)))
+		//a whole id cannot be split further
+		if size <= comparison.leafSize || len(record.UUIDChildPrefixes(key)) == 0 {
+			err = v.compareLeaf(comparison, key, size)
+		} else {
+			err = v.compareBuckets(comparison, key)
+		}
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+	}
+
+	return nil
+}
+
+// compareLeaf compares the hash of each record in a bucket, then the full records whose hashes differ
+func (v *Validator) compareLeaf(comparison merkleComparison, prefix string, size int) error {
+	dbHashes, err := comparison.sourceStore.GetRowHashesByIDPrefix(prefix, size, comparison.cutoff)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	esHashes, err := comparison.indexStore.GetDocumentHashesByIDPrefix(prefix, size, comparison.cutoff)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	comparison.result.HashesRead += len(dbHashes) + len(esHashes)
+
+	var differing, inDBOnly, inESOnly []string
+	for id, hash := range dbHashes {
+		esHash, ok := esHashes[id]
+		if !ok {
+			inDBOnly = append(inDBOnly, id)
+		} else if hash != esHash {
+			differing = append(differing, id)
+		}
+	}
+	for id := range esHashes {
+		if _, ok := dbHashes[id]; !ok {
+			inESOnly = append(inESOnly, id)
+		}
+	}
+
+	//a record modified after the cutoff is only excluded from one store when the other still has its previous copy,
+	//so the ids in one store are checked again without the cutoff and the content of the ids in both is compared
+	if len(inDBOnly)+len(inESOnly) > 0 {
+		mismatchedIds := append(append([]string{}, inDBOnly...), inESOnly...)
+		mismatchedDBIds, err := v.SourceStore.GetIDsByIDList(mismatchedIds)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		mismatchedESIds, err := v.IndexStore.GetIDsByIDList(mismatchedIds)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		_, inDBOnly, inESOnly = v.validateIdChunk(mismatchedDBIds, mismatchedESIds)
+		differing = append(differing, removeIDs(mismatchedDBIds, inDBOnly)...)
+	}
+	comparison.result.Categorized.add(inDBOnly, CategoryMissingInIndex)
+	comparison.result.Categorized.add(inESOnly, CategoryOrphanedInIndex)
+	sort.Strings(differing)
+
+	contentChunkSize := v.ContentChunkSize
+	if contentChunkSize < 1 {
+		contentChunkSize = 20
+	}
+	for start := 0; start < len(differing); start += contentChunkSize {
+		end := utils.Min(start+contentChunkSize, len(differing))
+
+		_, contentMismatches, err := v.validateFullChunkSync(differing[start:end])
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		for id, category := range contentMismatches {
+			if category != CategoryInFlight {
+				comparison.result.Categorized[id] = category
+			}
+		}
+	}
+
+	return nil
+}
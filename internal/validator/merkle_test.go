@@ -0,0 +1,93 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+// uuid pads a prefix to a UUID, e.g. 123 to 12300000-0000-0000-0000-000000000000
+func uuid(prefix string) string {
+	return prefix + "00000-0000-0000-0000-000000000000"
+}
+
+var _ = Describe("Merkle range comparison", func() {
+	var validator Validator
+	var now time.Time
+	var indexStore *test.InMemoryStore
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			Now:                        now,
+			RootNode:                   "host",
+			ContentChunkSize:           10,
+			ContentMaxThreads:          1,
+			InvalidThresholdPercentage: 5,
+			Merkle:                     true,
+			MerkleLeafSize:             10,
+		}
+
+		var records []map[string]interface{}
+		for i := 0; i < 1024; i++ {
+			records = append(records, test.HostRecord(uuid(fmt.Sprintf("%03x", i)), "name", now.Add(-time.Hour)))
+		}
+		validator.SourceStore, indexStore = test.HostStores(records...)
+		validator.IndexStore = indexStore
+	})
+
+	It("should compare only the top level buckets when every range matches", func() {
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.Merkle.BucketsCompared).To(Equal(4))
+		Expect(response.Details.Merkle.BucketsDiffering).To(Equal(0))
+		Expect(response.Details.Merkle.HashesRead).To(Equal(0))
+	})
+
+	It("should recurse into the differing ranges to find the mismatched records", func() {
+		indexStore.Records[0x123]["host"].(map[string]interface{})["display_name"] = "different"
+		Expect(indexStore.DeleteDocuments([]string{uuid("2ab")})).To(Succeed())
+		indexStore.Records = append(indexStore.Records, test.HostRecord(uuid("fff"), "name", now.Add(-time.Hour)))
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.Merkle.MismatchedIDs).To(Equal([]string{uuid("123"), uuid("2ab"), uuid("fff")}))
+		Expect(response.Mismatches).To(Equal(CategorizedMismatches{
+			uuid("123"): CategoryContentMismatch,
+			uuid("2ab"): CategoryMissingInIndex,
+			uuid("fff"): CategoryOrphanedInIndex,
+		}))
+		Expect(response.Details.Merkle.HashesRead).To(BeNumerically("<", 100))
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+	})
+
+	It("should not report the records modified after the lag compensation", func() {
+		validator.LagCompSec = 60
+		sourceStore := validator.SourceStore.(*test.InMemoryStore)
+		sourceStore.Records[0x123]["host"].(map[string]interface{})["display_name"] = "updated"
+		sourceStore.Records[0x123]["host"].(map[string]interface{})["modified_on"] = now
+		sourceStore.Records = append(sourceStore.Records, test.HostRecord(uuid("fff"), "new", now))
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.Merkle.BucketsDiffering).To(BeNumerically(">", 0))
+		Expect(response.Mismatches).To(BeEmpty())
+	})
+
+	It("should be invalid when the mismatches exceed the threshold", func() {
+		validator.InvalidThresholdPercentage = 0
+		indexStore.Records[0]["host"].(map[string]interface{})["display_name"] = "different"
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Reason).To(Equal("range mismatch"))
+	})
+})
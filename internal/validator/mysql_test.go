@@ -0,0 +1,87 @@
+package validator_test
+
+import (
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/database"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MySQL dialect", func() {
+	var dbClient *database.DBClient
+	var dbMock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		schemaParser := avro.SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/full")}
+		parsedSchema, err := schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+
+		rowFilter, err := filter.Parse(`[{"field": "deleted", "operator": "ne", "value": true}]`)
+		Expect(err).ToNot(HaveOccurred())
+
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		Expect(err).ToNot(HaveOccurred())
+		dbMock = mock
+
+		dialect, err := database.DialectByName("mysql")
+		Expect(err).ToNot(HaveOccurred())
+
+		dbClient = database.NewTestDBClient(sqlx.NewDb(mockDB, "sqlmock"), database.DBParams{
+			Table:            "hosts",
+			Dialect:          dialect,
+			Filter:           rowFilter,
+			ParsedAvroSchema: parsedSchema,
+		})
+	})
+
+	AfterEach(func() {
+		Expect(dbMock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("should use the MySQL syntax for the filter and timestamps", func() {
+		start := time.Date(2023, 1, 1, 1, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+		end := start.Add(time.Hour)
+
+		dbMock.ExpectQuery("SELECT id FROM hosts WHERE modified_on > '2023-01-01 06:00:00' AND " +
+			"modified_on < '2023-01-01 07:00:00' AND (NOT (deleted <=> true)) ORDER BY id ").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+
+		ids, err := dbClient.GetIDsByModifiedOn(start, end)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1"}))
+	})
+
+	It("should escape backslashes in the id list", func() {
+		dbMock.ExpectQuery(`SELECT id FROM hosts WHERE id in ('a\\''b') AND (NOT (deleted <=> true))`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		ids, err := dbClient.GetIDsByIDList([]string{`a\'b`})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(BeEmpty())
+	})
+
+	It("should sample with RAND instead of TABLESAMPLE", func() {
+		dbMock.ExpectQuery("SELECT id FROM hosts WHERE RAND() < 0.100000 AND (NOT (deleted <=> true)) ORDER BY id").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+
+		ids, err := dbClient.SampleIDs(10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1"}))
+	})
+
+	It("should not support the n_live_tup estimate", func() {
+		_, err := database.MySQLDialect{}.EstimateCountQuery("hosts", "n_live_tup")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an unknown dialect", func() {
+		_, err := database.DialectByName("oracle")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,190 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/opensearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OpenSearch index", func() {
+	var validator Validator
+	var dbMock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		testEnv := test.BeforeEach()
+		validator = testEnv.Validator
+		dbMock = testEnv.DBMock
+
+		osClient, err := opensearch.NewOSClient(opensearch.OSParams{
+			Url:      "http://mock-os:9200",
+			Index:    "mockindex",
+			RootNode: "host",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		validator.IndexStore = osClient
+	})
+
+	AfterEach(func() {
+		httpmock.DeactivateAndReset()
+	})
+
+	It("should validate count", func() {
+		dbMock.ExpectQuery("SELECT count(*) from hosts").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("1"))
+
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_count",
+			httpmock.NewStringResponder(200, `{"count": 1}`))
+
+		result, err := validator.ValidateCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(result.ESCount).To(Equal(1))
+
+		info := httpmock.GetCallCountInfo()
+		Expect(info["POST http://mock-os:9200/mockindex/_count"]).To(Equal(1))
+	})
+
+	It("should validate ids", func() {
+		startTime := validator.Now.Add(-time.Duration(validator.PeriodMin) * time.Minute)
+		endTime := validator.Now.Add(-time.Duration(validator.LagCompSec) * time.Second)
+
+		dbMock.ExpectQuery(fmt.Sprintf(
+			`SELECT id FROM hosts WHERE modified_on > '%s' AND modified_on < '%s' ORDER BY id`,
+			startTime.Format(time.RFC3339Nano), endTime.Format(time.RFC3339Nano))).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1234"))
+
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search?_source=host.id&scroll=60000ms&size=5000&sort=_doc",
+			httpmock.NewStringResponder(200, test.LoadTestDataFile("elasticsearch/id/one.hit.response")))
+
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/_search/scroll",
+			httpmock.NewStringResponder(200, test.LoadTestDataFile("elasticsearch/id/empty.scroll.response")))
+
+		result, err := validator.ValidateIDs()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IDsAreValid).To(BeTrue())
+		Expect(result.TotalESRecordsRetrieved).To(Equal(1))
+	})
+
+	It("should read the ids of an id range", func() {
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search?_source=host.id&scroll=60000ms&size=5000&sort=_doc",
+			httpmock.NewStringResponder(200, test.LoadTestDataFile("elasticsearch/id/one.hit.response")))
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/_search/scroll",
+			httpmock.NewStringResponder(200, test.LoadTestDataFile("elasticsearch/id/empty.scroll.response")))
+
+		indexStore, ok := validator.IndexStore.(KeyRangeIndexStore)
+		Expect(ok).To(BeTrue())
+		ids, err := indexStore.GetIDsByIDRange("1000", "2000", validator.Now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1234"}))
+	})
+
+	It("should read the stored content hashes", func() {
+		osClient, err := opensearch.NewOSClient(opensearch.OSParams{
+			Url:       "http://mock-os:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			HashField: "host.content_hash",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search",
+			httpmock.NewStringResponder(200, `{"hits": {"hits": [{"_id": "1234", "_source": {"host": {"content_hash": "abc"}}}]}}`))
+
+		var indexStore HashIndexStore = osClient
+		hashes, err := indexStore.GetDocumentHashesByIDs([]string{"1234"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hashes).To(Equal(map[string]string{"1234": "abc"}))
+	})
+
+	It("should read the stored content hashes of an id prefix", func() {
+		osClient, err := opensearch.NewOSClient(opensearch.OSParams{
+			Url:       "http://mock-os:9200",
+			Index:     "mockindex",
+			RootNode:  "host",
+			HashField: "host.content_hash",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search",
+			httpmock.NewStringResponder(200, `{"hits": {"hits": [{"_id": "1234", "_source": {"host": {"content_hash": "abc"}}}]}}`))
+
+		var indexStore MerkleIndexStore = osClient
+		hashes, err := indexStore.GetDocumentHashesByIDPrefix("12", 10, validator.Now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hashes).To(Equal(map[string]string{"1234": "abc"}))
+	})
+
+	It("should count the documents of each window", func() {
+		osClient, err := opensearch.NewOSClient(opensearch.OSParams{
+			Url:      "http://mock-os:9200",
+			Index:    "mockindex",
+			RootNode: "host",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search",
+			httpmock.NewStringResponder(200, `{"aggregations": {"windows": {"buckets": [{"key": 1685613600000, "doc_count": 2}]}}}`))
+
+		var indexStore WindowedCountIndexStore = osClient
+		start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+		counts, err := indexStore.CountIndexByWindow("modified_on", "hour", start, start.Add(2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(counts).To(Equal(map[time.Time]int{start: 2}))
+	})
+
+	It("should count the documents of each tenant", func() {
+		osClient, err := opensearch.NewOSClient(opensearch.OSParams{
+			Url:      "http://mock-os:9200",
+			Index:    "mockindex",
+			RootNode: "host",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search",
+			httpmock.NewStringResponder(200, `{"aggregations": {"tenants": {"buckets": [{"key": {"tenant": "12345"}, "doc_count": 2}]}}}`))
+
+		var indexStore TenantIndexStore = osClient
+		counts, err := indexStore.CountIndexByTenant("org_id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(counts).To(Equal(map[string]int{"12345": 2}))
+	})
+
+	It("should read the tenant of each document", func() {
+		osClient, err := opensearch.NewOSClient(opensearch.OSParams{
+			Url:      "http://mock-os:9200",
+			Index:    "mockindex",
+			RootNode: "host",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		httpmock.RegisterResponder(
+			"POST",
+			"http://mock-os:9200/mockindex/_search",
+			httpmock.NewStringResponder(200, `{"hits": {"hits": [{"_id": "1234", "_source": {"host": {"org_id": "12345"}}}]}}`))
+
+		var indexStore TenantIndexStore = osClient
+		tenants, err := indexStore.GetDocumentTenantsByIDs("org_id", []string{"1234"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tenants).To(Equal(map[string]string{"1234": "12345"}))
+	})
+})
@@ -0,0 +1,319 @@
+package validator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/database"
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/record"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const idsSearchURL = "http://mock-es:9200/mockindex/_search?_source=host.id&scroll=60000ms&size=5000&sort=_doc"
+const searchURL = "http://mock-es:9200/mockindex/_search"
+
+var _ = Describe("Database and index queries", func() {
+	var dbClient *database.DBClient
+	var esClient *elasticsearch.ESClient
+	var dbMock sqlmock.Sqlmock
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		testEnv := test.BeforeEach()
+		dbMock = testEnv.DBMock
+		dbClient, esClient = test.QueryStores(testEnv.MockDB,
+			database.DBParams{ContentHashSQL: "md5(row_to_json(t)::text)"},
+			elasticsearch.ESParams{HashField: "host.hash"})
+	})
+
+	AfterEach(func() {
+		Expect(dbMock.ExpectationsWereMet()).To(Succeed())
+		httpmock.DeactivateAndReset()
+	})
+
+	expectBody := func(body map[string]interface{}, expected string) {
+		actual, err := json.Marshal(body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actual).To(MatchJSON(expected))
+	}
+
+	Context("incremental", func() {
+		It("should page the rows in id order before the cutoff", func() {
+			dbMock.ExpectQuery(`SELECT id FROM hosts WHERE id > '07' AND modified_on <= '2023-06-01T12:00:00Z' ORDER BY id LIMIT 4`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("08").AddRow("09"))
+
+			ids, err := dbClient.GetIDsAfter("07", 4, end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids).To(Equal([]string{"08", "09"}))
+		})
+
+		It("should start at the first row without a checkpoint", func() {
+			dbMock.ExpectQuery(`SELECT id FROM hosts WHERE modified_on <= '2023-06-01T12:00:00Z' ORDER BY id LIMIT 4`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("00"))
+
+			ids, err := dbClient.GetIDsAfter("", 4, end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids).To(Equal([]string{"00"}))
+		})
+
+		It("should read the documents in the id range before the cutoff", func() {
+			bodies := test.CaptureRequests("GET", idsSearchURL, test.LoadTestDataFile("elasticsearch/id/zero.hit.response"))
+
+			_, err := esClient.GetIDsByIDRange("07", "11", end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*bodies).To(HaveLen(1))
+			expectBody((*bodies)[0], `{"query": {"bool": {"filter": [
+				{"range": {"host.id": {"gt": "07", "lte": "11"}}},
+				{"range": {"host.modified_on": {"lte": "2023-06-01T12:00:00Z"}}}
+			]}}}`)
+		})
+	})
+
+	Context("hashes", func() {
+		It("should compute the row hashes with the content hash expression", func() {
+			dbMock.ExpectQuery(`SELECT id, md5(row_to_json(t)::text) AS hash FROM hosts AS t WHERE id IN ('1','2')`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow("1", "aa").AddRow("2", "bb"))
+
+			hashes, err := dbClient.GetRowHashesByIDs([]string{"1", "2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hashes).To(Equal(map[string]string{"1": "aa", "2": "bb"}))
+		})
+
+		It("should read only the stored hash of the documents", func() {
+			bodies := test.CaptureRequests("GET", searchURL, `{"hits": {"hits": [
+				{"_id": "1", "_source": {"host": {"hash": "aa"}}},
+				{"_id": "2", "_source": {"host": {"hash": "bb"}}}
+			]}}`)
+
+			hashes, err := esClient.GetDocumentHashesByIDs([]string{"1", "2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hashes).To(Equal(map[string]string{"1": "aa", "2": "bb"}))
+			expectBody((*bodies)[0], `{
+				"query": {"bool": {"filter": {"ids": {"values": ["1", "2"]}}}},
+				"size": 2,
+				"_source": ["host.hash"]
+			}`)
+		})
+
+		It("should not read the full row and document when the SQL and stored hashes are equal", func() {
+			dbMock.ExpectQuery(`SELECT id, md5(row_to_json(t)::text) AS hash FROM hosts AS t WHERE id IN ('1')`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow("1", "0cc175b9c0f1b6a831c399e269772661"))
+			bodies := test.CaptureRequests("GET", searchURL, `{"hits": {"hits": [
+				{"_id": "1", "_source": {"host": {"hash": "0cc175b9c0f1b6a831c399e269772661"}}}
+			]}}`)
+
+			validator := Validator{
+				SourceStore:       dbClient,
+				IndexStore:        esClient,
+				RootNode:          "host",
+				ContentChunkSize:  10,
+				ContentMaxThreads: 1,
+				HashFirstPass:     true,
+			}
+			validator.SetDBIDs([]string{"1"})
+			result, err := validator.ValidateContent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ContentIsValid).To(BeTrue())
+			Expect(result.HashMismatchCount).To(Equal(0))
+			Expect(*bodies).To(HaveLen(1))
+		})
+	})
+
+	Context("merkle", func() {
+		It("should sum the row hashes of each bucket in the id range of the prefix", func() {
+			dbMock.ExpectQuery(`SELECT substr(id::text, 1, 3) AS bucket, count(*), ` +
+				`sum(('x' || substr(md5(row_to_json(t)::text), 1, 16))::bit(64)::bigint)::text FROM hosts AS t ` +
+				`WHERE id >= 'ab000000-0000-0000-0000-000000000000' AND id < 'ac000000-0000-0000-0000-000000000000' ` +
+				`AND modified_on <= '2023-06-01T12:00:00Z' GROUP BY bucket`).
+				WillReturnRows(sqlmock.NewRows([]string{"bucket", "count", "sum"}).
+					AddRow("ab0", 2, "-1").AddRow("ab1", 1, "5"))
+
+			buckets, err := dbClient.GetRowBucketHashes("ab", end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buckets).To(Equal(map[string]record.BucketHash{
+				"ab0": {Count: 2, Hash: 1<<64 - 1},
+				"ab1": {Count: 1, Hash: 5},
+			}))
+		})
+
+		It("should read the row hashes of a leaf without an upper bound after the last prefix", func() {
+			dbMock.ExpectQuery(`SELECT id, md5(row_to_json(t)::text) AS hash FROM hosts AS t ` +
+				`WHERE id >= 'ffffffff-f000-0000-0000-000000000000' AND modified_on <= '2023-06-01T12:00:00Z'`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow("ffffffff-f000-0000-0000-000000000001", "aa"))
+
+			hashes, err := dbClient.GetRowHashesByIDPrefix("ffffffff-f", 10, end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hashes).To(Equal(map[string]string{"ffffffff-f000-0000-0000-000000000001": "aa"}))
+		})
+
+		It("should sum the stored hashes of each bucket with a scripted metric", func() {
+			bodies := test.CaptureRequests("GET", searchURL, `{"aggregations": {"buckets": {"buckets": {
+				"abcdef01-0": {"doc_count": 2, "hash": {"value": -1}},
+				"abcdef01-1": {"doc_count": 0, "hash": {"value": 0}}
+			}}}}`)
+
+			buckets, err := esClient.GetDocumentBucketHashes("abcdef01", end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buckets).To(Equal(map[string]record.BucketHash{"abcdef01-0": {Count: 2, Hash: 1<<64 - 1}}))
+
+			Expect((*bodies)[0]["query"]).To(Equal(map[string]interface{}{
+				"range": map[string]interface{}{"host.modified_on": map[string]interface{}{"lte": "2023-06-01T12:00:00Z"}},
+			}))
+			aggs := (*bodies)[0]["aggs"].(map[string]interface{})["buckets"].(map[string]interface{})
+			filters := aggs["filters"].(map[string]interface{})["filters"]
+			Expect(filters).To(HaveLen(16))
+			Expect(filters).To(HaveKeyWithValue("abcdef01-f",
+				map[string]interface{}{"prefix": map[string]interface{}{"host.id": "abcdef01-f"}}))
+			scriptedMetric := aggs["aggs"].(map[string]interface{})["hash"].(map[string]interface{})["scripted_metric"]
+			Expect(scriptedMetric).To(HaveKeyWithValue("params", map[string]interface{}{"field": "host.hash"}))
+			Expect(scriptedMetric).To(HaveKeyWithValue("combine_script", "return state.sum"))
+		})
+	})
+
+	Context("windows", func() {
+		It("should count the rows of each window", func() {
+			dbMock.ExpectQuery(`SELECT date_trunc('hour', modified_on::timestamptz AT TIME ZONE 'UTC') AS window_start, count(*) ` +
+				`FROM hosts WHERE modified_on >= '2023-06-01T10:00:00Z' AND modified_on < '2023-06-01T12:00:00Z' GROUP BY window_start`).
+				WillReturnRows(sqlmock.NewRows([]string{"window_start", "count"}).AddRow(start, 3))
+
+			counts, err := dbClient.CountTableByWindow("modified_on", "hour", start, end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(Equal(map[time.Time]int{start: 3}))
+		})
+
+		It("should count the documents of each window with a date histogram", func() {
+			bodies := test.CaptureRequests("GET", searchURL, `{"aggregations": {"windows": {"buckets": [
+				{"key": 1685613600000, "doc_count": 3}
+			]}}}`)
+
+			counts, err := esClient.CountIndexByWindow("modified_on", "hour", start, end)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(Equal(map[time.Time]int{start: 3}))
+			expectBody((*bodies)[0], `{
+				"size": 0,
+				"query": {"range": {"host.modified_on": {"gte": "2023-06-01T10:00:00Z", "lt": "2023-06-01T12:00:00Z"}}},
+				"aggs": {"windows": {"date_histogram": {
+					"field": "host.modified_on", "calendar_interval": "hour", "min_doc_count": 1, "time_zone": "UTC"
+				}}}
+			}`)
+		})
+	})
+
+	Context("tenants", func() {
+		It("should count the rows of each tenant", func() {
+			dbMock.ExpectQuery(`SELECT org_id::text, count(*) FROM hosts GROUP BY 1`).
+				WillReturnRows(sqlmock.NewRows([]string{"org_id", "count"}).AddRow("a", 2).AddRow(nil, 1))
+
+			counts, err := dbClient.CountTableByTenant("org_id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(Equal(map[string]int{"a": 2, "": 1}))
+		})
+
+		It("should read the tenant of each row", func() {
+			dbMock.ExpectQuery(`SELECT id, org_id::text FROM hosts WHERE id IN ('1','2')`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "org_id"}).AddRow("1", "a").AddRow("2", "b"))
+
+			tenants, err := dbClient.GetRowTenantsByIDs("org_id", []string{"1", "2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tenants).To(Equal(map[string]string{"1": "a", "2": "b"}))
+		})
+
+		It("should count the documents of each tenant with a composite aggregation", func() {
+			bodies := test.CaptureRequests("GET", searchURL, `{"aggregations": {"tenants": {
+				"after_key": {"tenant": 1},
+				"buckets": [{"key": {"tenant": 1}, "doc_count": 2}, {"key": {"tenant": null}, "doc_count": 1}]
+			}}}`)
+
+			counts, err := esClient.CountIndexByTenant("org_id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(Equal(map[string]int{"1": 2, "": 1}))
+			Expect(*bodies).To(HaveLen(1))
+			expectBody((*bodies)[0], `{
+				"size": 0,
+				"aggs": {"tenants": {"composite": {
+					"size": 10000,
+					"sources": [{"tenant": {"terms": {"field": "host.org_id", "missing_bucket": true}}}]
+				}}}
+			}`)
+		})
+
+		It("should page the tenants after the last key of a full page", func() {
+			var buckets []string
+			for i := 0; i < 10000; i++ {
+				buckets = append(buckets, fmt.Sprintf(`{"key": {"tenant": "%05d"}, "doc_count": 1}`, i))
+			}
+			pages := []string{
+				`{"aggregations": {"tenants": {"after_key": {"tenant": "09999"}, "buckets": [` + strings.Join(buckets, ",") + `]}}}`,
+				`{"aggregations": {"tenants": {"buckets": [{"key": {"tenant": "10000"}, "doc_count": 1}]}}}`,
+			}
+			var bodies []map[string]interface{}
+			httpmock.RegisterResponder("GET", searchURL, func(req *http.Request) (*http.Response, error) {
+				defer GinkgoRecover()
+				var body map[string]interface{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				bodies = append(bodies, body)
+				return httpmock.NewStringResponse(200, pages[len(bodies)-1]), nil
+			})
+
+			counts, err := esClient.CountIndexByTenant("org_id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counts).To(HaveLen(10001))
+			Expect(bodies).To(HaveLen(2))
+			Expect(bodies[1]["aggs"].(map[string]interface{})["tenants"].(map[string]interface{})["composite"].(map[string]interface{})["after"]).
+				To(Equal(map[string]interface{}{"tenant": "09999"}))
+		})
+
+		It("should read the tenant of each document", func() {
+			bodies := test.CaptureRequests("GET", searchURL, `{"hits": {"hits": [
+				{"_id": "1", "_source": {"host": {"org_id": "a"}}},
+				{"_id": "2", "_source": {"host": {}}}
+			]}}`)
+
+			tenants, err := esClient.GetDocumentTenantsByIDs("org_id", []string{"1", "2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tenants).To(Equal(map[string]string{"1": "a", "2": ""}))
+			expectBody((*bodies)[0], `{
+				"query": {"bool": {"filter": {"ids": {"values": ["1", "2"]}}}},
+				"size": 2,
+				"_source": ["host.org_id"]
+			}`)
+		})
+	})
+
+	Context("connection", func() {
+		It("should quote the application name", func() {
+			connStr, err := database.PostgresDialect{}.ConnectionString(database.DBParams{
+				User:            "user",
+				Password:        "pass",
+				SSLMode:         "disable",
+				Name:            "db",
+				ApplicationName: `it's a \ name`,
+			}, "localhost", "5432")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connStr).To(Equal(
+				`host=localhost user=user password=pass port=5432 sslmode=disable timezone=UTC application_name='it\'s a \\ name' dbname=db`))
+		})
+	})
+
+	Context("estimate", func() {
+		It("should read the live tuples of an analyzed table", func() {
+			dbMock.ExpectQuery(`SELECT CASE WHEN last_analyze IS NULL AND last_autoanalyze IS NULL THEN -1 ELSE n_live_tup END ` +
+				`FROM pg_stat_user_tables WHERE relid = 'hosts'::regclass`).
+				WillReturnRows(sqlmock.NewRows([]string{"n_live_tup"}).AddRow(-1))
+
+			count, err := dbClient.EstimateTableCount("n_live_tup")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(-1))
+		})
+	})
+})
@@ -0,0 +1,62 @@
+package validator_test
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/database"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Read replica", func() {
+	var dbClient *database.DBClient
+	var primaryMock, replicaMock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		schemaParser := avro.SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/full")}
+		parsedSchema, err := schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+
+		primaryDB, primary, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		replicaDB, replica, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		primaryMock, replicaMock = primary, replica
+
+		dbClient = database.NewTestReplicaDBClient(sqlx.NewDb(primaryDB, "sqlmock"), sqlx.NewDb(replicaDB, "sqlmock"), database.DBParams{
+			Table:            "hosts",
+			ParsedAvroSchema: parsedSchema,
+		})
+	})
+
+	AfterEach(func() {
+		Expect(primaryMock.ExpectationsWereMet()).To(Succeed())
+		Expect(replicaMock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("should run the content queries on the replica", func() {
+		replicaMock.ExpectQuery(`SELECT .* FROM hosts WHERE ID IN \('1234'\) ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		rows, err := dbClient.GetRowsByIDs([]string{"1234"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(BeEmpty())
+	})
+
+	It("should run the count and id queries on the primary", func() {
+		primaryMock.ExpectQuery(`SELECT count\(\*\) from hosts`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("1"))
+		primaryMock.ExpectQuery(`SELECT id FROM hosts WHERE id in \('1234'\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1234"))
+
+		count, err := dbClient.CountTable()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+
+		ids, err := dbClient.GetIDsByIDList([]string{"1234"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1234"}))
+	})
+})
@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"strconv"
+
+	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+// ValidationResponse has the same JSON structure as the xjoin-go-lib ValidationResponse read by the xjoin-operator.
+// ResponseDetails adds details that are specific to this validator.
+type ValidationResponse struct {
+	Result  validation.ValidationResult `json:"result,omitempty"`
+	Reason  string                      `json:"reason,omitempty"`
+	Message string                      `json:"message,omitempty"`
+	Details ResponseDetails             `json:"details,omitempty"`
+
+	Mismatches CategorizedMismatches `json:"-"` //every mismatched id, details only contains the counts
+}
+
+type ResponseDetails struct {
+	Counts          validation.CountDetails     `json:"counts,omitempty"`
+	IDs             validation.IdsDetails       `json:"ids,omitempty"`
+	Content         validation.ContentDetails   `json:"content,omitempty"`
+	Indices         []string                    `json:"indices,omitempty"` //the concrete indices that were compared
+	IndexComparison *IndexComparison            `json:"indexComparison,omitempty"`
+	Consistency     *ConsistencyDetails         `json:"consistency,omitempty"`
+	Categories      MismatchCategories          `json:"categories"`
+	Persistent      *PersistentMismatches       `json:"persistent,omitempty"`
+	ContentSample   *SampleEstimate             `json:"contentSample,omitempty"`
+	Incremental     *ValidateIncrementalResult  `json:"incremental,omitempty"`
+	Merkle          *ValidateMerkleResult       `json:"merkle,omitempty"`
+	CountWindows    *ValidateCountWindowsResult `json:"countWindows,omitempty"`
+	Tenants         *TenantBreakdown            `json:"tenants,omitempty"`
+}
+
+// PersistentMismatches splits the mismatches into ids that mismatched in each of the last Runs runs and transient ones
+type PersistentMismatches struct {
+	Runs           int      `json:"runs"`
+	IDs            []string `json:"ids,omitempty"`
+	Count          int      `json:"count"`
+	TransientCount int      `json:"transientCount"`
+}
+
+func countDetails(countResponse ValidateCountResult) validation.CountDetails {
+	return validation.CountDetails{
+		InconsistencyAbsolute:      countResponse.MismatchCount,
+		InconsistencyRatio:         strconv.FormatFloat(countResponse.MismatchRatio, 'f', 4, 64),
+		RecordCountInElasticsearch: countResponse.ESCount,
+		RecordCountInDatabase:      countResponse.DBCount,
+	}
+}
+
+func idsDetails(idsResponse ValidateIDsResult) validation.IdsDetails {
+	return validation.IdsDetails{
+		InconsistencyAbsolute:            idsResponse.MismatchCount,
+		InconsistencyRatio:               strconv.FormatFloat(idsResponse.MismatchRatio, 'f', 4, 64),
+		AmountValidated:                  idsResponse.TotalDBRecordsRetrieved,
+		IdsMissingFromElasticsearch:      idsResponse.InDBOnly[:utils.Min(50, len(idsResponse.InDBOnly))],
+		IdsMissingFromElasticsearchCount: len(idsResponse.InDBOnly),
+		IdsOnlyInElasticsearch:           idsResponse.InESOnly[:utils.Min(50, len(idsResponse.InESOnly))],
+		IdsOnlyInElasticsearchCount:      len(idsResponse.InESOnly),
+	}
+}
+
+func contentDetails(contentResponse ValidateContentResult) validation.ContentDetails {
+	return validation.ContentDetails{
+		InconsistencyAbsolute:  contentResponse.MismatchCount,
+		InconsistencyRatio:     strconv.FormatFloat(contentResponse.MismatchRatio, 'f', 4, 64),
+		AmountValidated:        contentResponse.TotalRecordsValidated,
+		IdsWithMismatchContent: contentResponse.MismatchedIDs,
+		MismatchContentDetails: contentResponse.MismatchedRecords,
+	}
+}
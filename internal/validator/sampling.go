@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+const (
+	SampleRandom      = "random"      //random ids of the rows modified during the validation period
+	SampleStratified  = "stratified"  //the same share of random ids from each range of the sorted ids
+	SampleTableSample = "tablesample" //ids drawn by the database, e.g. with TABLESAMPLE in Postgres
+)
+
+const sampleStrata = 16
+
+// SamplingSourceStore is a SourceStore that can draw a random sample of ids
+type SamplingSourceStore interface {
+	SourceStore
+	SampleIDs(percentage float64) (ids []string, err error)
+}
+
+// SampleEstimate is the mismatch rate of the population estimated from a sample
+type SampleEstimate struct {
+	Method                 string  `json:"method"`
+	SampleSize             int     `json:"sampleSize"`
+	PopulationSize         int     `json:"populationSize"`
+	MismatchCount          int     `json:"mismatchCount"`
+	EstimatedMismatchRatio float64 `json:"estimatedMismatchRatio"`
+	ConfidenceLevel        float64 `json:"confidenceLevel"`
+	LowerBound             float64 `json:"lowerBound"`
+	UpperBound             float64 `json:"upperBound"`
+}
+
+// sampleSize is SampleSize when set, otherwise SamplePercentage of the population
+func (v *Validator) sampleSize(populationSize int) int {
+	size := v.SampleSize
+	if size <= 0 {
+		size = int(math.Ceil(float64(populationSize) * v.SamplePercentage / 100))
+	}
+	if size > populationSize {
+		size = populationSize
+	}
+	return size
+}
+
+// sampledIDs draws the sample before the ids are compared, so only the sampled ids are read from the index instead of
+// every id of both stores. Documents that are only in the index cannot be found by a sample of the database rows.
+func (v *Validator) sampledIDs(startTime time.Time, endTime time.Time) (dbIds []string, esIds []string, err error) {
+	dbIds, v.samplePopulationSize, err = v.sampleIDs(startTime, endTime)
+	if err != nil {
+		return dbIds, esIds, errors.Wrap(err, 0)
+	}
+	v.Log.Debug("sampled ids", "method", v.SampleMethod, "sample size", len(dbIds), "population size", v.samplePopulationSize)
+
+	if len(dbIds) == 0 {
+		return
+	}
+	esIds, err = v.IndexStore.GetIDsByIDList(dbIds)
+	if err != nil {
+		return dbIds, esIds, errors.Wrap(err, 0)
+	}
+	return
+}
+
+// sampleIDs returns the sampled database ids and the size of the population they are drawn from.
+// Random and stratified samples are drawn from the ids of the rows modified between startTime and endTime,
+// tablesample is drawn by the database from the whole table.
+func (v *Validator) sampleIDs(startTime time.Time, endTime time.Time) (ids []string, populationSize int, err error) {
+	random := rand.New(rand.NewSource(v.Now.UnixNano()))
+
+	switch v.SampleMethod {
+	case SampleRandom, SampleStratified:
+		var population []string
+		population, err = v.SourceStore.GetIDsByModifiedOn(startTime, endTime)
+		if err != nil {
+			return ids, populationSize, errors.Wrap(err, 0)
+		}
+
+		populationSize = len(population)
+		if v.SampleMethod == SampleRandom {
+			ids = randomSample(population, v.sampleSize(populationSize), random)
+		} else {
+			ids = stratifiedSample(population, v.sampleSize(populationSize), random)
+		}
+	case SampleTableSample:
+		sourceStore, ok := v.SourceStore.(SamplingSourceStore)
+		if !ok {
+			return ids, populationSize, errors.Wrap(errors.New("tablesample is not supported by the source store"), 0)
+		}
+
+		populationSize = v.dbCount
+		percentage := float64(v.sampleSize(populationSize)) / math.Max(float64(populationSize), 1) * 100
+		ids, err = sourceStore.SampleIDs(percentage)
+		if err != nil {
+			return ids, populationSize, errors.Wrap(err, 0)
+		}
+	default:
+		return ids, populationSize, errors.Wrap(errors.New("invalid sample method: "+v.SampleMethod), 0)
+	}
+
+	sort.Strings(ids)
+	return
+}
+
+func randomSample(ids []string, size int, random *rand.Rand) (sample []string) {
+	for _, i := range random.Perm(len(ids))[:size] {
+		sample = append(sample, ids[i])
+	}
+	return
+}
+
+// stratifiedSample splits the sorted ids into equal ranges and draws the same share of each range
+func stratifiedSample(ids []string, size int, random *rand.Rand) (sample []string) {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+
+	strataSize := int(math.Ceil(float64(len(sorted)) / sampleStrata))
+	for start := 0; start < len(sorted); start += strataSize {
+		end := start + strataSize
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+		stratum := sorted[start:end]
+
+		stratumSampleSize := int(math.Round(float64(size) * float64(len(stratum)) / float64(len(sorted))))
+		sample = append(sample, randomSample(stratum, stratumSampleSize, random)...)
+	}
+	return
+}
+
+func (v *Validator) sampleEstimate(mismatchCount int, sampleSize int, populationSize int) *SampleEstimate {
+	confidenceLevel := v.SampleConfidenceLevel
+	if confidenceLevel <= 0 || confidenceLevel >= 1 {
+		confidenceLevel = 0.95
+	}
+
+	estimate, lower, upper := estimateMismatchRatio(mismatchCount, sampleSize, confidenceLevel)
+	return &SampleEstimate{
+		Method:                 v.SampleMethod,
+		SampleSize:             sampleSize,
+		PopulationSize:         populationSize,
+		MismatchCount:          mismatchCount,
+		EstimatedMismatchRatio: estimate,
+		ConfidenceLevel:        confidenceLevel,
+		LowerBound:             lower,
+		UpperBound:             upper,
+	}
+}
+
+// estimateMismatchRatio uses the Wilson score interval, which stays within [0, 1] for small samples and rare mismatches
+func estimateMismatchRatio(mismatchCount int, sampleSize int, confidenceLevel float64) (estimate float64, lower float64, upper float64) {
+	if sampleSize == 0 {
+		return 0, 0, 1
+	}
+
+	n := float64(sampleSize)
+	estimate = float64(mismatchCount) / n
+	z := math.Sqrt2 * math.Erfinv(confidenceLevel)
+
+	denominator := 1 + z*z/n
+	center := (estimate + z*z/(2*n)) / denominator
+	margin := z * math.Sqrt(estimate*(1-estimate)/n+z*z/(4*n*n)) / denominator
+
+	return estimate, math.Max(0, center-margin), math.Min(1, center+margin)
+}
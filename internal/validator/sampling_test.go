@@ -0,0 +1,109 @@
+package validator_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+// sampledIndexStore fails when the ids of the validation period are read instead of only the sampled ids
+type sampledIndexStore struct {
+	*test.InMemoryStore
+}
+
+func (s sampledIndexStore) GetIDsByModifiedOn(_ time.Time, _ time.Time) ([]string, error) {
+	return nil, errors.New("the index ids should not be read when sampling")
+}
+
+var _ = Describe("Sampling content validation", func() {
+	var validator Validator
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			PeriodMin:                  100,
+			Now:                        now,
+			RootNode:                   "host",
+			ContentChunkSize:           10,
+			ContentMaxThreads:          1,
+			InvalidThresholdPercentage: 5,
+			SampleSize:                 20,
+		}
+
+		var records []map[string]interface{}
+		for i := 0; i < 100; i++ {
+			records = append(records, test.HostRecord(fmt.Sprintf("%03d", i), "name", now.Add(-time.Hour)))
+		}
+		validator.SourceStore, validator.IndexStore = test.HostStores(records...)
+	})
+
+	It("should estimate the mismatch rate from a random sample", func() {
+		validator.SampleMethod = SampleRandom
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.Content.AmountValidated).To(Equal(20))
+		Expect(*response.Details.ContentSample).To(Equal(SampleEstimate{
+			Method:                 SampleRandom,
+			SampleSize:             20,
+			PopulationSize:         100,
+			MismatchCount:          0,
+			EstimatedMismatchRatio: 0,
+			ConfidenceLevel:        0.95,
+			LowerBound:             0,
+			UpperBound:             response.Details.ContentSample.UpperBound,
+		}))
+		Expect(response.Details.ContentSample.UpperBound).To(BeNumerically("~", 0.1611, 0.0001))
+	})
+
+	It("should only read the sampled ids from the index", func() {
+		validator.SampleMethod = SampleRandom
+		validator.IndexStore = sampledIndexStore{InMemoryStore: validator.IndexStore.(*test.InMemoryStore)}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.IDs.AmountValidated).To(Equal(20))
+		Expect(response.Details.Content.AmountValidated).To(Equal(20))
+	})
+
+	It("should draw the same share of each range of ids for a stratified sample", func() {
+		validator.SampleMethod = SampleStratified
+		validator.SampleSize = 0
+		validator.SamplePercentage = 32
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.ContentSample.SampleSize).To(BeNumerically("~", 32, 4))
+	})
+
+	It("should evaluate the threshold against the estimated mismatch rate", func() {
+		validator.SampleMethod = SampleRandom
+		validator.SampleSize = 100
+		for _, record := range validator.IndexStore.(*test.InMemoryStore).Records[:10] {
+			record["host"].(map[string]interface{})["display_name"] = "different"
+		}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Details.ContentSample.EstimatedMismatchRatio).To(Equal(0.1))
+		Expect(response.Details.ContentSample.LowerBound).To(BeNumerically("<", 0.1))
+		Expect(response.Details.ContentSample.UpperBound).To(BeNumerically(">", 0.1))
+	})
+
+	It("should fail when the source store cannot draw a table sample", func() {
+		validator.SampleMethod = SampleTableSample
+
+		_, err := validator.Validate()
+		Expect(err).To(HaveOccurred())
+	})
+})
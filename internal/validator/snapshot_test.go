@@ -0,0 +1,67 @@
+package validator_test
+
+import (
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/RedHatInsights/xjoin-validation/internal/avro"
+	"github.com/RedHatInsights/xjoin-validation/internal/database"
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Database snapshot", func() {
+	var dbClient *database.DBClient
+	var dbMock sqlmock.Sqlmock
+
+	BeforeEach(func() {
+		schemaParser := avro.SchemaParser{FullSchemaString: test.LoadTestDataFile("avro/full")}
+		parsedSchema, err := schemaParser.Parse()
+		Expect(err).ToNot(HaveOccurred())
+
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		Expect(err).ToNot(HaveOccurred())
+		dbMock = mock
+
+		dbClient = database.NewTestDBClient(sqlx.NewDb(mockDB, "sqlmock"), database.DBParams{
+			Table:            "hosts",
+			ParsedAvroSchema: parsedSchema,
+		})
+	})
+
+	AfterEach(func() {
+		Expect(dbMock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("should run the queries after BeginSnapshot in the snapshot transaction", func() {
+		snapshotTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+		modifiedOn := snapshotTime.Add(-time.Minute)
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectQuery("SELECT now()").WillReturnRows(sqlmock.NewRows([]string{"now"}).AddRow(snapshotTime))
+		dbMock.ExpectQuery("SELECT id, modified_on FROM hosts WHERE id in ('1')").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "modified_on"}).AddRow("1", modifiedOn))
+		dbMock.ExpectQuery("SELECT id FROM hosts WHERE id in ('1')").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+		dbMock.ExpectRollback()
+
+		started, err := dbClient.BeginSnapshot()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(started).To(Equal(snapshotTime))
+
+		//the transaction is rolled back asynchronously when its context is cancelled
+		time.Sleep(10 * time.Millisecond)
+
+		modified, err := dbClient.GetModifiedOnByIDs([]string{"1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(modified).To(Equal(map[string]time.Time{"1": modifiedOn}))
+
+		ids, err := dbClient.GetIDsByIDList([]string{"1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"1"}))
+
+		Expect(dbClient.EndSnapshot()).To(Succeed())
+	})
+})
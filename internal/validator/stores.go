@@ -0,0 +1,20 @@
+package validator
+
+import "time"
+
+// SourceStore is the datastore that is the source of truth for the validation, e.g. the database read by Debezium
+type SourceStore interface {
+	CountTable() (count int, err error)
+	GetIDsByModifiedOn(start time.Time, end time.Time) (ids []string, err error)
+	GetIDsByIDList(ids []string) (responseIds []string, err error)
+	GetRowsByIDs(ids []string) (records []map[string]interface{}, err error)
+}
+
+// IndexStore is the datastore that is validated against the SourceStore, e.g. the Elasticsearch index
+type IndexStore interface {
+	CountIndex() (count int, err error)
+	GetIDsByModifiedOn(start time.Time, end time.Time) (ids []string, err error)
+	GetIDsByIDList(ids []string) (responseIds []string, err error)
+	GetDocumentsByIDs(ids []string) (records []map[string]interface{}, err error)
+	ResolveIndices() (indices []string, err error)
+}
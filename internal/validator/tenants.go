@@ -0,0 +1,146 @@
+package validator
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-errors/errors"
+)
+
+// TenantSourceStore is a SourceStore that can group the rows by a tenant column, e.g. org_id or account
+type TenantSourceStore interface {
+	SourceStore
+	CountTableByTenant(column string) (counts map[string]int, err error)
+	GetRowTenantsByIDs(column string, ids []string) (tenants map[string]string, err error)
+}
+
+// TenantIndexStore is an IndexStore that can group the documents by a tenant field
+type TenantIndexStore interface {
+	IndexStore
+	CountIndexByTenant(field string) (counts map[string]int, err error)
+	GetDocumentTenantsByIDs(field string, ids []string) (tenants map[string]string, err error)
+}
+
+type TenantResult struct {
+	Tenant          string             `json:"tenant"`
+	DBCount         int                `json:"dbCount"`
+	ESCount         int                `json:"esCount"`
+	CountDifference int                `json:"countDifference"`
+	MismatchCount   int                `json:"mismatchCount"` //the mismatched ids of the tenant, excluding in flight ids
+	Categories      MismatchCategories `json:"categories"`
+}
+
+type TenantBreakdown struct {
+	Column          string         `json:"column"`
+	TenantsCompared int            `json:"tenantsCompared"`
+	TenantsAffected int            `json:"tenantsAffected"` //the tenants with a count difference or mismatched ids
+	WorstTenants    []TenantResult `json:"worstTenants,omitempty"`
+}
+
+// ValidateTenants breaks the counts and mismatches down by TenantColumn and reports the TenantReportLimit tenants
+// with the most mismatches
+func (v *Validator) ValidateTenants(mismatches CategorizedMismatches) (result TenantBreakdown, err error) {
+	sourceStore, ok := v.SourceStore.(TenantSourceStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("tenant breakdown is not supported by the source store"), 0)
+	}
+	indexStore, ok := v.IndexStore.(TenantIndexStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("tenant breakdown is not supported by the index backend"), 0)
+	}
+	result.Column = v.TenantColumn
+
+	dbCounts, err := sourceStore.CountTableByTenant(v.TenantColumn)
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+	esCounts, err := indexStore.CountIndexByTenant(v.TenantColumn)
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+
+	tenants := make(map[string]*TenantResult)
+	tenant := func(name string) *TenantResult {
+		if tenants[name] == nil {
+			tenants[name] = &TenantResult{Tenant: name}
+		}
+		return tenants[name]
+	}
+	for name, count := range dbCounts {
+		tenant(name).DBCount = count
+	}
+	for name, count := range esCounts {
+		tenant(name).ESCount = count
+	}
+
+	//orphaned ids are only in the index, every other mismatched id has a row
+	var rowIds, documentIds []string
+	for id, category := range mismatches {
+		if category == CategoryOrphanedInIndex {
+			documentIds = append(documentIds, id)
+		} else {
+			rowIds = append(rowIds, id)
+		}
+	}
+	idTenants := make(map[string]string)
+	if len(rowIds) > 0 {
+		idTenants, err = sourceStore.GetRowTenantsByIDs(v.TenantColumn, rowIds)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+	}
+	if len(documentIds) > 0 {
+		documentTenants, err := indexStore.GetDocumentTenantsByIDs(v.TenantColumn, documentIds)
+		if err != nil {
+			return result, errors.Wrap(err, 0)
+		}
+		for id, name := range documentTenants {
+			idTenants[id] = name
+		}
+	}
+
+	tenantMismatches := make(map[string]CategorizedMismatches)
+	for id, category := range mismatches {
+		name := idTenants[id]
+		if tenantMismatches[name] == nil {
+			tenantMismatches[name] = make(CategorizedMismatches)
+		}
+		tenantMismatches[name][id] = category
+	}
+	for name, categorized := range tenantMismatches {
+		t := tenant(name)
+		t.Categories = categorized.Counts()
+		t.MismatchCount = len(categorized) - t.Categories.InFlight
+	}
+
+	var affected []TenantResult
+	for _, t := range tenants {
+		t.CountDifference = int(math.Abs(float64(t.DBCount - t.ESCount)))
+		if t.CountDifference > 0 || t.MismatchCount > 0 {
+			affected = append(affected, *t)
+		}
+	}
+	sort.Slice(affected, func(i, j int) bool {
+		if affected[i].MismatchCount != affected[j].MismatchCount {
+			return affected[i].MismatchCount > affected[j].MismatchCount
+		}
+		if affected[i].CountDifference != affected[j].CountDifference {
+			return affected[i].CountDifference > affected[j].CountDifference
+		}
+		return affected[i].Tenant < affected[j].Tenant
+	})
+
+	result.TenantsCompared = len(tenants)
+	result.TenantsAffected = len(affected)
+
+	limit := v.TenantReportLimit
+	if limit < 1 {
+		limit = 10
+	}
+	if len(affected) > limit {
+		affected = affected[:limit]
+	}
+	result.WorstTenants = affected
+
+	return
+}
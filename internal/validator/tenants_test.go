@@ -0,0 +1,115 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func tenantRecord(id string, orgID string, displayName string, modifiedOn time.Time) map[string]interface{} {
+	record := test.HostRecord(id, displayName, modifiedOn)
+	record["host"].(map[string]interface{})["org_id"] = orgID
+	return record
+}
+
+var _ = Describe("Per-tenant breakdown", func() {
+	var validator Validator
+	var now time.Time
+	var indexStore *test.InMemoryStore
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			PeriodMin:                  100,
+			Now:                        now,
+			RootNode:                   "host",
+			ContentChunkSize:           10,
+			ContentMaxThreads:          1,
+			InvalidThresholdPercentage: 50,
+			TenantColumn:               "org_id",
+		}
+
+		var records []map[string]interface{}
+		for _, orgID := range []string{"a", "b", "c"} {
+			for i := 0; i < 10; i++ {
+				records = append(records, tenantRecord(fmt.Sprintf("%s%02d", orgID, i), orgID, "name", now.Add(-time.Minute)))
+			}
+		}
+		validator.SourceStore, indexStore = test.HostStores(records...)
+		validator.IndexStore = indexStore
+	})
+
+	It("should list the tenants with the most content mismatches first", func() {
+		indexStore.Records[0]["host"].(map[string]interface{})["display_name"] = "different"
+		indexStore.Records[10]["host"].(map[string]interface{})["display_name"] = "different"
+		indexStore.Records[11]["host"].(map[string]interface{})["display_name"] = "different"
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*response.Details.Tenants).To(Equal(TenantBreakdown{
+			Column:          "org_id",
+			TenantsCompared: 3,
+			TenantsAffected: 2,
+			WorstTenants: []TenantResult{
+				{
+					Tenant:        "b",
+					DBCount:       10,
+					ESCount:       10,
+					MismatchCount: 2,
+					Categories:    MismatchCategories{ContentMismatch: 2},
+				},
+				{
+					Tenant:        "a",
+					DBCount:       10,
+					ESCount:       10,
+					MismatchCount: 1,
+					Categories:    MismatchCategories{ContentMismatch: 1},
+				},
+			},
+		}))
+	})
+
+	It("should look up the tenant of orphaned documents in the index", func() {
+		Expect(indexStore.DeleteDocuments([]string{"b01"})).To(Succeed())
+		indexStore.Records = append(indexStore.Records, tenantRecord("c99", "c", "name", now.Add(-time.Minute)))
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Reason).To(Equal("id mismatch"))
+		Expect(response.Details.Tenants.WorstTenants).To(Equal([]TenantResult{
+			{
+				Tenant:          "b",
+				DBCount:         10,
+				ESCount:         9,
+				CountDifference: 1,
+				MismatchCount:   1,
+				Categories:      MismatchCategories{MissingInIndex: 1},
+			},
+			{
+				Tenant:          "c",
+				DBCount:         10,
+				ESCount:         11,
+				CountDifference: 1,
+				MismatchCount:   1,
+				Categories:      MismatchCategories{OrphanedInIndex: 1},
+			},
+		}))
+	})
+
+	It("should report the count difference of each tenant when the counts do not match", func() {
+		Expect(indexStore.DeleteDocuments([]string{"c00", "c01", "c02", "c03", "c04", "c05", "c06", "c07"})).To(Succeed())
+		validator.TenantReportLimit = 1
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Reason).To(Equal("count mismatch"))
+		Expect(response.Details.Tenants.TenantsAffected).To(Equal(1))
+		Expect(response.Details.Tenants.WorstTenants).To(Equal([]TenantResult{
+			{Tenant: "c", DBCount: 10, ESCount: 2, CountDifference: 8},
+		}))
+	})
+})
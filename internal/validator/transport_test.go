@@ -0,0 +1,106 @@
+package validator_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// newCertificate returns a certificate for 127.0.0.1 signed by parent, or self-signed when parent is nil
+func newCertificate(isCA bool, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "xjoin-validation test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	Expect(err).ToNot(HaveOccurred())
+	leaf, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func fingerprint(cert tls.Certificate) string {
+	digest := sha256.Sum256(cert.Certificate[0])
+	return hex.EncodeToString(digest[:])
+}
+
+var _ = Describe("Certificate fingerprint", func() {
+	var ca tls.Certificate
+
+	BeforeEach(func() {
+		ca = newCertificate(true, nil)
+	})
+
+	get := func(serverCert tls.Certificate, pinned string) error {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+		server.StartTLS()
+		defer server.Close()
+
+		transport, err := elasticsearch.NewTransport(elasticsearch.ESParams{
+			CertificateFingerprint: pinned,
+			Transport:              &http.Transport{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		res, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	It("should accept a certificate signed by the pinned CA", func() {
+		leaf := newCertificate(false, &ca)
+		leaf.Certificate = append(leaf.Certificate, ca.Certificate[0])
+
+		Expect(get(leaf, fingerprint(ca))).To(Succeed())
+	})
+
+	It("should accept the pinned certificate", func() {
+		Expect(get(ca, fingerprint(ca))).To(Succeed())
+	})
+
+	It("should reject a certificate that is not signed by the pinned CA when the CA is also presented", func() {
+		leaf := newCertificate(false, nil)
+		leaf.Certificate = append(leaf.Certificate, ca.Certificate[0])
+
+		Expect(get(leaf, fingerprint(ca))).ToNot(Succeed())
+	})
+
+	It("should reject a chain without the pinned certificate", func() {
+		leaf := newCertificate(false, &ca)
+
+		Expect(get(leaf, fingerprint(ca))).ToNot(Succeed())
+	})
+})
@@ -4,37 +4,72 @@ import (
 	"encoding/json"
 	"fmt"
 	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
-	"strconv"
 	"time"
 
-	. "github.com/RedHatInsights/xjoin-validation/internal/database"
-	. "github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
 	"github.com/go-errors/errors"
-	"github.com/redhatinsights/xjoin-go-lib/pkg/utils"
 	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
 )
 
 type Validator struct {
-	DBClient
-	ESClient
+	SourceStore
+	IndexStore
 	PeriodMin                  int  //the amount of time to look back when selecting data to validate
 	LagCompSec                 int  //the amount of time subtracted from NOW when selecting data to validate
 	ValidateEverything         bool //when true, validate the entire dataset. This will ignore PeriodMin
 	ContentMaxThreads          int  //the number of concurrent threads when validating content
 	ContentChunkSize           int  //the number of records to validate in each chunk during content validation
 	InvalidThresholdPercentage int
+	ConsistencyMode            bool //when true, compare a database snapshot with an index point in time
+	TransformedFields          []string
+	SampleMethod               string  //when set, only validate the content of a random, stratified or tablesample sample
+	SampleSize                 int     //the number of records in the sample, takes precedence over SamplePercentage
+	SamplePercentage           float64 //the percentage of records in the sample
+	SampleConfidenceLevel      float64 //the confidence level of the estimated mismatch ratio, defaults to 0.95
+	Incremental                bool    //when true, validate the next chunks of the table in id order instead of the ids and content
+	IncrementalChunkSize       int     //the number of ids in each incremental chunk
+	IncrementalChunks          int     //the number of incremental chunks to validate in each run, 0 validates until the end of the table
+	CheckpointStore            CheckpointStore
+	HashFirstPass              bool    //when true, compare content hashes first and only compare the full records whose hashes differ
+	Merkle                     bool    //when true, compare the hash sums of ranges of ids instead of the ids and content
+	MerkleLeafSize             int     //the number of records in a range below which the hash of each record is compared
+	CountWindowField           string  //when set, also compare the counts in each time window of this timestamp field
+	CountWindowInterval        string  //the length of each count window: minute, hour or day
+	CountWindows               int     //the number of count windows ending now to compare
+	TenantColumn               string  //when set, break the counts and mismatches down by this column, e.g. org_id
+	TenantReportLimit          int     //the number of tenants with the most mismatches to report
+	CountEstimateMethod        string  //when set, compare an estimate of the number of rows, reltuples or n_live_tup, with the index count
+	CountEstimateMargin        float64 //the rows are counted when the estimated mismatch ratio is within this margin of the threshold
+	CheckpointKey              string
 	Now                        time.Time
 	RootNode                   string
 	dbIds                      []string
+	samplePopulationSize       int
 	Log                        logger.Log
 	dbCount                    int
+	indexWriteTime             time.Time
+	hashMismatches             int64
 }
 
 func (v *Validator) SetDBCount(count int) {
 	v.dbCount = count
 }
 
-func (v *Validator) Validate() (response validation.ValidationResponse, err error) {
+func (v *Validator) Validate() (response ValidationResponse, err error) {
+	response, err = v.validate()
+	if err != nil || v.TenantColumn == "" {
+		return
+	}
+
+	tenants, err := v.ValidateTenants(response.Mismatches)
+	if err != nil {
+		return response, errors.Wrap(err, 0)
+	}
+	response.Details.Tenants = &tenants
+
+	return
+}
+
+func (v *Validator) validate() (response ValidationResponse, err error) {
 	//f, err := os.OpenFile("/tmp/validation.profile.txt", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
 	//if err != nil {
 	//	os.Exit(1)
@@ -42,30 +77,59 @@ func (v *Validator) Validate() (response validation.ValidationResponse, err erro
 	//trace.Start(f)
 	//defer trace.Stop()
 
+	indices, err := v.IndexStore.ResolveIndices()
+	if err != nil {
+		return response, errors.Wrap(err, 0)
+	}
+	if len(indices) > 1 {
+		v.Log.Warn("Index resolves to multiple concrete indices, they will be validated as one", "indices", indices)
+	}
+	response.Details.Indices = indices
+
+	var consistency ConsistencyDetails
+	if v.ConsistencyMode {
+		consistency, err = v.beginConsistentRead()
+		if err != nil {
+			return response, errors.Wrap(err, 0)
+		}
+		defer v.endConsistentRead()
+		response.Details.Consistency = &consistency
+
+		//the database snapshot can only run one query at a time
+		v.ContentMaxThreads = 1
+	}
+
 	countResponse, err := v.ValidateCount()
 	if err != nil {
 		return response, errors.Wrap(err, 0)
 	}
+	response.Details.Counts = countDetails(countResponse)
+
+	//the windows are also compared when the count is invalid, to report where the counts diverge
+	if v.CountWindowField != "" {
+		windowsResponse, err := v.ValidateCountWindows()
+		if err != nil {
+			return response, errors.Wrap(err, 0)
+		}
+		response.Details.CountWindows = &windowsResponse
+
+		if countResponse.CountIsValid && !windowsResponse.CountIsValid {
+			response.Result = validation.ValidationInvalid
+			response.Reason = "count window mismatch"
+			response.Message = fmt.Sprintf(
+				"%v discrepancies in %v of %v %s windows of %s.",
+				windowsResponse.MismatchCount, len(windowsResponse.DivergentWindows), windowsResponse.WindowsCompared,
+				windowsResponse.Interval, windowsResponse.Field)
+			return response, nil
+		}
+	}
 
 	if !countResponse.CountIsValid {
-		message := fmt.Sprintf(
+		response.Result = validation.ValidationInvalid
+		response.Reason = "count mismatch"
+		response.Message = fmt.Sprintf(
 			"%v discrepancies while counting. %v documents in elasticsearch. %v rows in database.",
 			countResponse.MismatchCount, countResponse.ESCount, countResponse.DBCount)
-
-		response = validation.ValidationResponse{
-			Result:  validation.ValidationInvalid,
-			Reason:  "count mismatch",
-			Message: message,
-			Details: validation.ResponseDetails{
-				Counts: validation.CountDetails{
-					InconsistencyAbsolute:      countResponse.MismatchCount,
-					InconsistencyRatio:         strconv.FormatFloat(countResponse.MismatchRatio, 'f', 4, 64),
-					RecordCountInElasticsearch: countResponse.ESCount,
-					RecordCountInDatabase:      countResponse.DBCount,
-				},
-			},
-		}
-
 		return
 	} else {
 		countResponseString, err := json.Marshal(countResponse)
@@ -75,39 +139,64 @@ func (v *Validator) Validate() (response validation.ValidationResponse, err erro
 		fmt.Println(string(countResponseString))
 	}
 
+	if v.Incremental {
+		incrementalResponse, err := v.ValidateIncremental()
+		if err != nil {
+			return response, errors.Wrap(err, 0)
+		}
+		response.Details.Incremental = &incrementalResponse
+		response.Mismatches = incrementalResponse.Categorized
+		response.Details.Categories = response.Mismatches.Counts()
+
+		if !incrementalResponse.IsValid {
+			response.Result = validation.ValidationInvalid
+			response.Reason = "incremental mismatch"
+			response.Message = fmt.Sprintf(
+				"%v of %v records did not match.",
+				incrementalResponse.MismatchCount, incrementalResponse.RecordsValidated)
+		} else {
+			response.Result = validation.ValidationValid
+		}
+		return response, nil
+	}
+
+	if v.Merkle {
+		merkleResponse, err := v.ValidateMerkle()
+		if err != nil {
+			return response, errors.Wrap(err, 0)
+		}
+		response.Details.Merkle = &merkleResponse
+		response.Mismatches = merkleResponse.Categorized
+		response.Details.Categories = response.Mismatches.Counts()
+
+		if !merkleResponse.IsValid {
+			response.Result = validation.ValidationInvalid
+			response.Reason = "range mismatch"
+			response.Message = fmt.Sprintf(
+				"%v records did not match in %v differing ranges.",
+				merkleResponse.MismatchCount, merkleResponse.BucketsDiffering)
+		} else {
+			response.Result = validation.ValidationValid
+		}
+		return response, nil
+	}
+
 	idsResponse, err := v.ValidateIDs()
 	if err != nil {
 		return response, errors.Wrap(err, 0)
 	}
+	response.Details.IDs = idsDetails(idsResponse)
+	response.Mismatches = make(CategorizedMismatches)
+	response.Mismatches.merge(idsResponse.Categorized)
+	response.Details.Categories = response.Mismatches.Counts()
+	consistency.addInFlight(idsResponse.InFlight)
 
 	if !idsResponse.IDsAreValid {
-		message := fmt.Sprintf(
+		response.Result = validation.ValidationInvalid
+		response.Reason = "id mismatch"
+		response.Message = fmt.Sprintf(
 			"%v ids did not match.",
 			idsResponse.MismatchCount)
-
-		response = validation.ValidationResponse{
-			Result:  validation.ValidationInvalid,
-			Reason:  "id mismatch",
-			Message: message,
-			Details: validation.ResponseDetails{
-				Counts: validation.CountDetails{
-					InconsistencyAbsolute:      countResponse.MismatchCount,
-					InconsistencyRatio:         strconv.FormatFloat(countResponse.MismatchRatio, 'f', 4, 64),
-					RecordCountInElasticsearch: countResponse.ESCount,
-					RecordCountInDatabase:      countResponse.DBCount,
-				},
-				IDs: validation.IdsDetails{
-					InconsistencyAbsolute:            idsResponse.MismatchCount,
-					InconsistencyRatio:               strconv.FormatFloat(idsResponse.MismatchRatio, 'f', 4, 64),
-					AmountValidated:                  idsResponse.TotalDBRecordsRetrieved,
-					IdsMissingFromElasticsearch:      idsResponse.InDBOnly[:utils.Min(50, len(idsResponse.InDBOnly))],
-					IdsMissingFromElasticsearchCount: len(idsResponse.InDBOnly),
-					IdsOnlyInElasticsearch:           idsResponse.InESOnly[:utils.Min(50, len(idsResponse.InESOnly))],
-					IdsOnlyInElasticsearchCount:      len(idsResponse.InESOnly),
-				},
-			},
-		}
-
 		return
 	} else {
 		idsResponseString, err := json.Marshal(idsResponse)
@@ -121,40 +210,18 @@ func (v *Validator) Validate() (response validation.ValidationResponse, err erro
 	if err != nil {
 		return response, errors.Wrap(err, 0)
 	}
+	response.Details.Content = contentDetails(contentResponse)
+	response.Details.ContentSample = contentResponse.Sample
+	response.Mismatches.merge(contentResponse.Categorized)
+	response.Details.Categories = response.Mismatches.Counts()
+	consistency.addInFlight(contentResponse.InFlightIDs)
 
 	if !contentResponse.ContentIsValid {
-		message := fmt.Sprintf(
+		response.Result = validation.ValidationInvalid
+		response.Message = fmt.Sprintf(
 			"%v record's contents did not match.",
 			contentResponse.MismatchCount)
-
-		return validation.ValidationResponse{
-			Result:  validation.ValidationInvalid,
-			Message: message,
-			Details: validation.ResponseDetails{
-				Counts: validation.CountDetails{
-					InconsistencyAbsolute:      countResponse.MismatchCount,
-					InconsistencyRatio:         strconv.FormatFloat(countResponse.MismatchRatio, 'f', 4, 64),
-					RecordCountInElasticsearch: countResponse.ESCount,
-					RecordCountInDatabase:      countResponse.DBCount,
-				},
-				IDs: validation.IdsDetails{
-					InconsistencyAbsolute:            idsResponse.MismatchCount,
-					InconsistencyRatio:               strconv.FormatFloat(idsResponse.MismatchRatio, 'f', 4, 64),
-					AmountValidated:                  idsResponse.TotalDBRecordsRetrieved,
-					IdsMissingFromElasticsearch:      idsResponse.InDBOnly[:utils.Min(50, len(idsResponse.InDBOnly))],
-					IdsMissingFromElasticsearchCount: len(idsResponse.InDBOnly),
-					IdsOnlyInElasticsearch:           idsResponse.InESOnly[:utils.Min(50, len(idsResponse.InESOnly))],
-					IdsOnlyInElasticsearchCount:      len(idsResponse.InESOnly),
-				},
-				Content: validation.ContentDetails{
-					InconsistencyAbsolute:  contentResponse.MismatchCount,
-					InconsistencyRatio:     strconv.FormatFloat(contentResponse.MismatchRatio, 'f', 4, 64),
-					AmountValidated:        contentResponse.TotalRecordsValidated,
-					IdsWithMismatchContent: contentResponse.MismatchedIDs,
-					MismatchContentDetails: contentResponse.MismatchedRecords,
-				},
-			},
-		}, nil
+		return
 	} else {
 		contentResponseString, err := json.Marshal(contentResponse)
 		if err != nil {
@@ -163,33 +230,8 @@ func (v *Validator) Validate() (response validation.ValidationResponse, err erro
 		fmt.Println(string(contentResponseString))
 	}
 
-	return validation.ValidationResponse{
-		Result: validation.ValidationValid,
-		Details: validation.ResponseDetails{
-			Counts: validation.CountDetails{
-				InconsistencyAbsolute:      countResponse.MismatchCount,
-				InconsistencyRatio:         strconv.FormatFloat(countResponse.MismatchRatio, 'f', 4, 64),
-				RecordCountInElasticsearch: countResponse.ESCount,
-				RecordCountInDatabase:      countResponse.DBCount,
-			},
-			IDs: validation.IdsDetails{
-				InconsistencyAbsolute:            idsResponse.MismatchCount,
-				InconsistencyRatio:               strconv.FormatFloat(idsResponse.MismatchRatio, 'f', 4, 64),
-				AmountValidated:                  idsResponse.TotalDBRecordsRetrieved,
-				IdsMissingFromElasticsearch:      idsResponse.InDBOnly[:utils.Min(50, len(idsResponse.InDBOnly))],
-				IdsMissingFromElasticsearchCount: len(idsResponse.InDBOnly),
-				IdsOnlyInElasticsearch:           idsResponse.InESOnly[:utils.Min(50, len(idsResponse.InESOnly))],
-				IdsOnlyInElasticsearchCount:      len(idsResponse.InESOnly),
-			},
-			Content: validation.ContentDetails{
-				InconsistencyAbsolute:  contentResponse.MismatchCount,
-				InconsistencyRatio:     strconv.FormatFloat(contentResponse.MismatchRatio, 'f', 4, 64),
-				AmountValidated:        contentResponse.TotalRecordsValidated,
-				IdsWithMismatchContent: contentResponse.MismatchedIDs,
-				MismatchContentDetails: contentResponse.MismatchedRecords,
-			},
-		},
-	}, nil
+	response.Result = validation.ValidationValid
+	return
 }
 
 func (v *Validator) SetDBIDs(dbIds []string) {
@@ -0,0 +1,92 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Validate with in-memory stores", func() {
+	var validator Validator
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Now()
+		validator = Validator{
+			PeriodMin:         100,
+			LagCompSec:        0,
+			Now:               now,
+			RootNode:          "host",
+			ContentChunkSize:  10,
+			ContentMaxThreads: 1,
+		}
+	})
+
+	It("should be valid when both stores contain the same records", func() {
+		records := []map[string]interface{}{
+			test.HostRecord("1234", "first", now.Add(-time.Minute)),
+			test.HostRecord("5678", "second", now.Add(-time.Minute)),
+		}
+		validator.SourceStore, validator.IndexStore = test.HostStores(records...)
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationValid))
+		Expect(response.Details.IDs.AmountValidated).To(Equal(2))
+		Expect(response.Details.Content.AmountValidated).To(Equal(2))
+	})
+
+	It("should be invalid when the index is missing a record", func() {
+		validator.SourceStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{
+			test.HostRecord("1234", "first", now.Add(-time.Minute)),
+			test.HostRecord("5678", "second", now.Add(-time.Minute)),
+		}}
+		validator.IndexStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{
+			test.HostRecord("1234", "first", now.Add(-time.Minute)),
+		}}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Reason).To(Equal("count mismatch"))
+	})
+
+	It("should be invalid when record contents differ", func() {
+		validator.SourceStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{
+			test.HostRecord("1234", "first", now.Add(-time.Minute)),
+		}}
+		validator.IndexStore = &test.InMemoryStore{RootNode: "host", Records: []map[string]interface{}{
+			test.HostRecord("1234", "different", now.Add(-time.Minute)),
+		}}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Details.Content.IdsWithMismatchContent).To(Equal([]string{"1234"}))
+	})
+
+	It("should report every mismatched record when more than 50 chunks differ", func() {
+		var dbRecords, esDocuments []map[string]interface{}
+		for i := 0; i < 120; i++ {
+			id := fmt.Sprintf("%03d", i)
+			dbRecords = append(dbRecords, test.HostRecord(id, "first", now.Add(-time.Minute)))
+			esDocuments = append(esDocuments, test.HostRecord(id, "different", now.Add(-time.Minute)))
+		}
+		validator.SourceStore = &test.InMemoryStore{RootNode: "host", Records: dbRecords}
+		validator.IndexStore = &test.InMemoryStore{RootNode: "host", Records: esDocuments}
+		validator.ContentChunkSize = 1
+		validator.ContentMaxThreads = 4
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.Content.InconsistencyAbsolute).To(Equal(120))
+		Expect(response.Details.Content.IdsWithMismatchContent).To(HaveLen(120))
+		Expect(response.Details.Content.MismatchContentDetails).To(HaveLen(50))
+		Expect(response.Mismatches).To(HaveLen(120))
+	})
+})
@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+const (
+	WindowMinute = "minute"
+	WindowHour   = "hour"
+	WindowDay    = "day"
+)
+
+// WindowedCountSourceStore is a SourceStore that can count the rows in each time window of a timestamp column
+type WindowedCountSourceStore interface {
+	SourceStore
+	CountTableByWindow(field string, interval string, start time.Time, end time.Time) (counts map[time.Time]int, err error)
+}
+
+// WindowedCountIndexStore is an IndexStore that can count the documents in each time window of a timestamp field
+type WindowedCountIndexStore interface {
+	IndexStore
+	CountIndexByWindow(field string, interval string, start time.Time, end time.Time) (counts map[time.Time]int, err error)
+}
+
+type CountWindow struct {
+	Start   time.Time `json:"start"`
+	DBCount int       `json:"dbCount"`
+	ESCount int       `json:"esCount"`
+}
+
+type ValidateCountWindowsResult struct {
+	Field            string        `json:"field"`
+	Interval         string        `json:"interval"`
+	WindowsCompared  int           `json:"windowsCompared"`
+	DivergentWindows []CountWindow `json:"divergentWindows,omitempty"`
+	MismatchCount    int           `json:"mismatchCount"` //the sum of the differences of each window
+	MismatchRatio    float64       `json:"mismatchRatio"`
+	CountIsValid     bool          `json:"countIsValid"`
+}
+
+// WindowDuration is the length of a count window interval
+func WindowDuration(interval string) (time.Duration, error) {
+	switch interval {
+	case WindowMinute:
+		return time.Minute, nil
+	case WindowHour, "":
+		return time.Hour, nil
+	case WindowDay:
+		return 24 * time.Hour, nil
+	default:
+		return 0, errors.Wrap(errors.New("invalid count window interval: "+interval), 0)
+	}
+}
+
+// ValidateCountWindows compares the counts of each of the last CountWindows windows of CountWindowField.
+// Unlike the whole-table count, missing documents in one window cannot be masked by orphaned documents in another.
+func (v *Validator) ValidateCountWindows() (result ValidateCountWindowsResult, err error) {
+	sourceStore, ok := v.SourceStore.(WindowedCountSourceStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("windowed counts are not supported by the source store"), 0)
+	}
+	indexStore, ok := v.IndexStore.(WindowedCountIndexStore)
+	if !ok {
+		return result, errors.Wrap(errors.New("windowed counts are not supported by the index backend"), 0)
+	}
+
+	interval := v.CountWindowInterval
+	if interval == "" {
+		interval = WindowHour
+	}
+	duration, err := WindowDuration(interval)
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+	numWindows := v.CountWindows
+	if numWindows < 1 {
+		numWindows = 24
+	}
+
+	//the rows modified within the lag compensation may not be indexed yet
+	end := v.Now.Add(-time.Duration(v.LagCompSec) * time.Second).UTC()
+	start := end.Truncate(duration).Add(-time.Duration(numWindows-1) * duration)
+	result.Field = v.CountWindowField
+	result.Interval = interval
+
+	v.Log.Debug("Starting windowed count validation", "field", v.CountWindowField, "interval", interval, "start", start, "end", end)
+
+	dbCounts, err := sourceStore.CountTableByWindow(v.CountWindowField, interval, start, end)
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+	esCounts, err := indexStore.CountIndexByWindow(v.CountWindowField, interval, start, end)
+	if err != nil {
+		return result, errors.Wrap(err, 0)
+	}
+
+	windows := make(map[int64]*CountWindow)
+	window := func(windowStart time.Time) *CountWindow {
+		key := windowStart.Unix()
+		if windows[key] == nil {
+			windows[key] = &CountWindow{Start: windowStart.UTC()}
+		}
+		return windows[key]
+	}
+	for windowStart, count := range dbCounts {
+		window(windowStart).DBCount += count
+	}
+	for windowStart, count := range esCounts {
+		window(windowStart).ESCount += count
+	}
+
+	var dbTotal, esTotal int
+	for _, w := range windows {
+		result.WindowsCompared++
+		dbTotal += w.DBCount
+		esTotal += w.ESCount
+		if w.DBCount != w.ESCount {
+			result.MismatchCount += int(math.Abs(float64(w.DBCount - w.ESCount)))
+			result.DivergentWindows = append(result.DivergentWindows, *w)
+		}
+	}
+	sort.Slice(result.DivergentWindows, func(i, j int) bool {
+		return result.DivergentWindows[i].Start.Before(result.DivergentWindows[j].Start)
+	})
+
+	//the same tolerance as the whole-table count
+	result.MismatchRatio = math.Round(float64(result.MismatchCount)/math.Max(math.Max(float64(dbTotal), float64(esTotal)), 1)*100) / 100
+	result.CountIsValid = result.MismatchRatio <= countMismatchThreshold
+
+	return
+}
@@ -0,0 +1,97 @@
+package validator_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RedHatInsights/xjoin-validation/internal/test"
+	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
+)
+
+var _ = Describe("Windowed count validation", func() {
+	var validator Validator
+	var now time.Time
+	var indexStore *test.InMemoryStore
+
+	BeforeEach(func() {
+		now = time.Date(2023, 6, 1, 12, 30, 0, 0, time.UTC)
+		validator = Validator{
+			PeriodMin:                  100,
+			Now:                        now,
+			RootNode:                   "host",
+			ContentChunkSize:           10,
+			ContentMaxThreads:          1,
+			InvalidThresholdPercentage: 5,
+			CountWindowField:           "modified_on",
+			CountWindowInterval:        WindowHour,
+			CountWindows:               5,
+		}
+
+		var records []map[string]interface{}
+		for i := 0; i < 20; i++ {
+			modifiedOn := now.Add(-5*time.Minute - time.Duration(i)*10*time.Minute)
+			records = append(records, test.HostRecord(fmt.Sprintf("%03d", i), "name", modifiedOn))
+		}
+		validator.SourceStore, indexStore = test.HostStores(records...)
+		validator.IndexStore = indexStore
+	})
+
+	It("should compare each window when the counts match", func() {
+		result, err := validator.ValidateCountWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(result.WindowsCompared).To(Equal(4))
+		Expect(result.DivergentWindows).To(BeEmpty())
+	})
+
+	It("should report missing documents that are masked by orphaned documents in the whole-table count", func() {
+		Expect(indexStore.DeleteDocuments([]string{"000", "001", "002"})).To(Succeed())
+		for i := 0; i < 3; i++ {
+			indexStore.Records = append(indexStore.Records,
+				test.HostRecord(fmt.Sprintf("orphan-%d", i), "name", now.Add(-3*time.Hour-time.Duration(i)*time.Minute)))
+		}
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Details.Counts.InconsistencyAbsolute).To(Equal(0))
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Reason).To(Equal("count window mismatch"))
+		Expect(response.Details.CountWindows.MismatchCount).To(Equal(6))
+		Expect(response.Details.CountWindows.DivergentWindows).To(Equal([]CountWindow{
+			{Start: time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC), DBCount: 5, ESCount: 8},
+			{Start: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC), DBCount: 3, ESCount: 0},
+		}))
+	})
+
+	It("should report the divergent windows when the whole-table count is invalid", func() {
+		Expect(indexStore.DeleteDocuments([]string{"000", "001", "002", "003", "004"})).To(Succeed())
+
+		response, err := validator.Validate()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Result).To(Equal(validation.ValidationInvalid))
+		Expect(response.Reason).To(Equal("count mismatch"))
+		Expect(response.Details.CountWindows).ToNot(BeNil())
+		Expect(response.Details.CountWindows.MismatchCount).To(Equal(5))
+	})
+
+	It("should not count the rows modified within the lag compensation", func() {
+		validator.LagCompSec = 120
+		sourceStore := validator.SourceStore.(*test.InMemoryStore)
+		sourceStore.Records = append(sourceStore.Records, test.HostRecord("new", "name", now.Add(-time.Minute)))
+
+		result, err := validator.ValidateCountWindows()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.CountIsValid).To(BeTrue())
+		Expect(result.DivergentWindows).To(BeEmpty())
+	})
+
+	It("should reject an invalid interval", func() {
+		validator.CountWindowInterval = "fortnight"
+
+		_, err := validator.ValidateCountWindows()
+		Expect(err).To(HaveOccurred())
+	})
+})
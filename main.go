@@ -2,46 +2,107 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/JeremyLoy/config"
 	"github.com/RedHatInsights/xjoin-validation/internal/avro"
 	. "github.com/RedHatInsights/xjoin-validation/internal/database"
 	. "github.com/RedHatInsights/xjoin-validation/internal/elasticsearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/filter"
+	"github.com/RedHatInsights/xjoin-validation/internal/history"
 	logger "github.com/RedHatInsights/xjoin-validation/internal/log"
 	"github.com/RedHatInsights/xjoin-validation/internal/metrics"
+	. "github.com/RedHatInsights/xjoin-validation/internal/opensearch"
+	"github.com/RedHatInsights/xjoin-validation/internal/remediation"
+	"github.com/RedHatInsights/xjoin-validation/internal/repair"
 	. "github.com/RedHatInsights/xjoin-validation/internal/validator"
 	"github.com/go-errors/errors"
+	validation "github.com/redhatinsights/xjoin-go-lib/pkg/validation"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type DatabaseConnectionInfo struct {
-	Name     string `json:"name"`
-	Hostname string `json:"hostname"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Port     string `json:"port"`
-	Table    string `json:"table"`
-	SSLMode  string `json:"sslMode"`
+	Name            string `json:"name"`
+	Hostname        string `json:"hostname"`
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	Port            string `json:"port"`
+	Table           string `json:"table"`
+	SSLMode         string `json:"sslMode"`
+	Dialect         string `json:"dialect"`
+	ReplicaHostname string `json:"replicaHostname"`
+	ReplicaPort     string `json:"replicaPort"`
 }
 
 type Config struct {
-	ElasticsearchHostUrl       string `config:"ELASTICSEARCH_HOST_URL"`
-	ElasticsearchIndex         string `config:"ELASTICSEARCH_INDEX"`
-	ElasticsearchPassword      string `config:"ELASTICSEARCH_PASSWORD"`
-	ElasticsearchUsername      string `config:"ELASTICSEARCH_USERNAME"`
-	DatabaseConnections        string `config:"DATABASE_CONNECTIONS"`
-	FullAvroSchema             string `config:"FULL_AVRO_SCHEMA"`
-	NumAttempts                int    `config:"NUM_ATTEMPTS"`
-	Interval                   int    `config:"INTERVAL"`
-	LagCompSec                 int    `config:"LAG_COMP_SEC"`
-	PeriodMin                  int    `config:"PERIOD_MIN"`
-	InvalidThresholdPercentage int    `config:"INVALID_THRESHOLD_PERCENTAGE"`
-	ValidateEverything         bool   `config:"VALIDATE_EVERYTHING"`
-	PrometheusPushGatewayUrl   string `config:"PROMETHEUS_PUSH_GATEWAY_URL"`
-	ContentMaxThreads          int    `config:"CONTENT_MAX_THREADS"`
-	ContentChunkSize           int    `config:"CONTENT_CHUNK_SIZE"`
+	ElasticsearchHostUrl       string  `config:"ELASTICSEARCH_HOST_URL"`
+	ElasticsearchIndex         string  `config:"ELASTICSEARCH_INDEX"`
+	ElasticsearchRefreshIndex  string  `config:"ELASTICSEARCH_REFRESHING_INDEX"`
+	ElasticsearchPassword      string  `config:"ELASTICSEARCH_PASSWORD"`
+	ElasticsearchUsername      string  `config:"ELASTICSEARCH_USERNAME"`
+	ElasticsearchUsernameFile  string  `config:"ELASTICSEARCH_USERNAME_FILE"`
+	ElasticsearchPasswordFile  string  `config:"ELASTICSEARCH_PASSWORD_FILE"`
+	ElasticsearchApiKey        string  `config:"ELASTICSEARCH_API_KEY"`
+	ElasticsearchApiKeyFile    string  `config:"ELASTICSEARCH_API_KEY_FILE"`
+	ElasticsearchServiceToken  string  `config:"ELASTICSEARCH_SERVICE_TOKEN"`
+	ElasticsearchFingerprint   string  `config:"ELASTICSEARCH_CERTIFICATE_FINGERPRINT"`
+	ElasticsearchCAPath        string  `config:"ELASTICSEARCH_CA_PATH"`
+	ElasticsearchClientCert    string  `config:"ELASTICSEARCH_CLIENT_CERT_PATH"`
+	ElasticsearchClientKey     string  `config:"ELASTICSEARCH_CLIENT_KEY_PATH"`
+	ElasticsearchInsecure      bool    `config:"ELASTICSEARCH_INSECURE_SKIP_VERIFY"`
+	ElasticsearchVersion       string  `config:"ELASTICSEARCH_VERSION"`
+	IndexBackend               string  `config:"INDEX_BACKEND"`
+	DatabaseConnections        string  `config:"DATABASE_CONNECTIONS"`
+	FullAvroSchema             string  `config:"FULL_AVRO_SCHEMA"`
+	NumAttempts                int     `config:"NUM_ATTEMPTS"`
+	Interval                   int     `config:"INTERVAL"`
+	LagCompSec                 int     `config:"LAG_COMP_SEC"`
+	PeriodMin                  int     `config:"PERIOD_MIN"`
+	InvalidThresholdPercentage int     `config:"INVALID_THRESHOLD_PERCENTAGE"`
+	ValidateEverything         bool    `config:"VALIDATE_EVERYTHING"`
+	PrometheusPushGatewayUrl   string  `config:"PROMETHEUS_PUSH_GATEWAY_URL"`
+	ContentMaxThreads          int     `config:"CONTENT_MAX_THREADS"`
+	ContentChunkSize           int     `config:"CONTENT_CHUNK_SIZE"`
+	ConsistencyMode            bool    `config:"CONSISTENCY_MODE"`
+	RemediationOutput          string  `config:"REMEDIATION_OUTPUT"`
+	RepairMethod               string  `config:"REPAIR_METHOD"`
+	RepairTouchSQL             string  `config:"REPAIR_TOUCH_SQL"`
+	RepairDryRun               bool    `config:"REPAIR_DRY_RUN"`
+	RepairRateLimit            int     `config:"REPAIR_RATE_LIMIT"`
+	RepairBatchSize            int     `config:"REPAIR_BATCH_SIZE"`
+	RepairAuditLog             string  `config:"REPAIR_AUDIT_LOG"`
+	HistoryPath                string  `config:"HISTORY_PATH"`
+	HistoryRetentionRuns       int     `config:"HISTORY_RETENTION_RUNS"`
+	HistoryMaxMismatches       int     `config:"HISTORY_MAX_MISMATCHES"`
+	PersistentMismatchRuns     int     `config:"PERSISTENT_MISMATCH_RUNS"`
+	ContentSampleMethod        string  `config:"CONTENT_SAMPLE_METHOD"`
+	ContentSampleSize          int     `config:"CONTENT_SAMPLE_SIZE"`
+	ContentSamplePercentage    float64 `config:"CONTENT_SAMPLE_PERCENTAGE"`
+	ContentSampleConfidence    float64 `config:"CONTENT_SAMPLE_CONFIDENCE"`
+	Incremental                bool    `config:"INCREMENTAL"`
+	IncrementalChunkSize       int     `config:"INCREMENTAL_CHUNK_SIZE"`
+	IncrementalChunksPerRun    int     `config:"INCREMENTAL_CHUNKS_PER_RUN"`
+	ContentHashFirstPass       bool    `config:"CONTENT_HASH_FIRST_PASS"`
+	ContentHashSQL             string  `config:"CONTENT_HASH_SQL"`
+	ContentHashField           string  `config:"CONTENT_HASH_FIELD"`
+	MerkleComparison           bool    `config:"MERKLE_COMPARISON"`
+	MerkleLeafSize             int     `config:"MERKLE_LEAF_SIZE"`
+	RowFilter                  string  `config:"ROW_FILTER"`
+	CountWindowField           string  `config:"COUNT_WINDOW_FIELD"`
+	CountWindowInterval        string  `config:"COUNT_WINDOW_INTERVAL"`
+	CountWindows               int     `config:"COUNT_WINDOWS"`
+	TenantColumn               string  `config:"TENANT_COLUMN"`
+	TenantReportLimit          int     `config:"TENANT_REPORT_LIMIT"`
+	CountEstimateMethod        string  `config:"COUNT_ESTIMATE_METHOD"`
+	CountEstimateMargin        float64 `config:"COUNT_ESTIMATE_MARGIN"`
+	DBMaxOpenConns             int     `config:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns             int     `config:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetimeSec       int     `config:"DB_CONN_MAX_LIFETIME_SEC"`
+	DBStatementTimeoutSec      int     `config:"DB_STATEMENT_TIMEOUT_SEC"`
+	DBApplicationName          string  `config:"DB_APPLICATION_NAME"`
 }
 
 func parseDatabaseConnectionFromEnv(datasourceName string) (dbConnectionInfo DatabaseConnectionInfo, err error) {
@@ -52,6 +113,9 @@ func parseDatabaseConnectionFromEnv(datasourceName string) (dbConnectionInfo Dat
 	dbConnectionInfo.Port = os.Getenv(datasourceName + "_DB_PORT")
 	dbConnectionInfo.Table = os.Getenv(datasourceName + "_DB_TABLE")
 	dbConnectionInfo.SSLMode = os.Getenv(datasourceName + "_DB_SSL_MODE")
+	dbConnectionInfo.Dialect = os.Getenv(datasourceName + "_DB_DIALECT")
+	dbConnectionInfo.ReplicaHostname = os.Getenv(datasourceName + "_DB_REPLICA_HOSTNAME")
+	dbConnectionInfo.ReplicaPort = os.Getenv(datasourceName + "_DB_REPLICA_PORT")
 
 	if dbConnectionInfo.Hostname == "" {
 		return dbConnectionInfo, errors.Wrap(errors.New(
@@ -78,6 +142,278 @@ func parseDatabaseConnectionFromEnv(datasourceName string) (dbConnectionInfo Dat
 	return
 }
 
+func newIndexStore(c Config, index string, parsedSchema avro.ParsedAvroSchema, rowFilter filter.Filter, log logger.Log) (IndexStore, error) {
+	esParams := ESParams{
+		Url:                    c.ElasticsearchHostUrl,
+		Username:               c.ElasticsearchUsername,
+		Password:               c.ElasticsearchPassword,
+		UsernameFile:           c.ElasticsearchUsernameFile,
+		PasswordFile:           c.ElasticsearchPasswordFile,
+		APIKey:                 c.ElasticsearchApiKey,
+		APIKeyFile:             c.ElasticsearchApiKeyFile,
+		ServiceToken:           c.ElasticsearchServiceToken,
+		CACertPath:             c.ElasticsearchCAPath,
+		ClientCertPath:         c.ElasticsearchClientCert,
+		ClientKeyPath:          c.ElasticsearchClientKey,
+		InsecureSkipVerify:     c.ElasticsearchInsecure,
+		CertificateFingerprint: c.ElasticsearchFingerprint,
+		Index:                  index,
+		RootNode:               parsedSchema.RootNode,
+		HashField:              c.ContentHashField,
+		Filter:                 rowFilter,
+		ParsedAvroSchema:       parsedSchema,
+		Log:                    log,
+	}
+
+	if esParams.InsecureSkipVerify {
+		log.Warn("Elasticsearch certificate verification is disabled")
+	}
+
+	switch strings.ToLower(c.IndexBackend) {
+	case "", "elasticsearch":
+		version := c.ElasticsearchVersion
+		if strings.EqualFold(version, "auto") {
+			major, err := DetectMajorVersion(esParams)
+			if err != nil {
+				return nil, errors.Wrap(err, 0)
+			}
+			version = strconv.Itoa(major)
+		}
+
+		switch version {
+		case "", "7":
+			return NewESClient(esParams)
+		case "8":
+			return NewES8Client(esParams)
+		default:
+			return nil, errors.Wrap(errors.New(
+				"unsupported ELASTICSEARCH_VERSION: "+version+". Expected 7, 8 or auto"), 0)
+		}
+	case "opensearch":
+		transport, err := NewTransport(esParams)
+		if err != nil {
+			return nil, errors.Wrap(err, 0)
+		}
+
+		return NewOSClient(OSParams{
+			Url:              c.ElasticsearchHostUrl,
+			Transport:        transport,
+			Index:            index,
+			RootNode:         parsedSchema.RootNode,
+			HashField:        c.ContentHashField,
+			Filter:           rowFilter,
+			ParsedAvroSchema: parsedSchema,
+			Log:              log,
+		})
+	default:
+		return nil, errors.Wrap(errors.New(
+			"invalid INDEX_BACKEND value: "+c.IndexBackend+". Expected elasticsearch or opensearch"), 0)
+	}
+}
+
+// validationAttempt is the response of a single attempt of validate
+type validationAttempt struct {
+	number    int
+	startedAt time.Time
+	response  ValidationResponse
+}
+
+// validate runs the validation until it is valid or c.NumAttempts is reached.
+// The response is the one of the last attempt, attempts contains every attempt so each is saved to the history.
+func validate(c Config, sourceStore SourceStore, indexStore IndexStore, index string, parsedSchema avro.ParsedAvroSchema,
+	checkpointStore CheckpointStore, log logger.Log) (response ValidationResponse, attempts []validationAttempt, err error) {
+	//TODO: auto retry if sync is progressing (i.e. new mismatch count < previous mismatch count)
+	i := 0
+	for i < c.NumAttempts {
+		log.Info("Validation attempt", "number", i)
+		startedAt := time.Now().UTC()
+		validator := Validator{
+			SourceStore:                sourceStore,
+			IndexStore:                 indexStore,
+			PeriodMin:                  c.PeriodMin,
+			LagCompSec:                 c.LagCompSec,
+			Now:                        time.Now().UTC(),
+			Log:                        log,
+			InvalidThresholdPercentage: c.InvalidThresholdPercentage,
+			RootNode:                   parsedSchema.RootNode,
+			TransformedFields:          parsedSchema.TransformedFields,
+			ValidateEverything:         c.ValidateEverything,
+			ContentChunkSize:           c.ContentChunkSize,
+			ContentMaxThreads:          c.ContentMaxThreads,
+			ConsistencyMode:            c.ConsistencyMode,
+			SampleMethod:               c.ContentSampleMethod,
+			SampleSize:                 c.ContentSampleSize,
+			SamplePercentage:           c.ContentSamplePercentage,
+			SampleConfidenceLevel:      c.ContentSampleConfidence,
+			Incremental:                c.Incremental,
+			IncrementalChunkSize:       c.IncrementalChunkSize,
+			IncrementalChunks:          c.IncrementalChunksPerRun,
+			HashFirstPass:              c.ContentHashFirstPass,
+			Merkle:                     c.MerkleComparison,
+			MerkleLeafSize:             c.MerkleLeafSize,
+			CountWindowField:           c.CountWindowField,
+			CountWindowInterval:        c.CountWindowInterval,
+			CountWindows:               c.CountWindows,
+			TenantColumn:               c.TenantColumn,
+			TenantReportLimit:          c.TenantReportLimit,
+			CountEstimateMethod:        c.CountEstimateMethod,
+			CountEstimateMargin:        c.CountEstimateMargin,
+			CheckpointStore:            checkpointStore,
+			CheckpointKey:              index,
+		}
+		response, err = validator.Validate()
+		if err != nil {
+			return response, attempts, errors.Wrap(err, 0)
+		}
+		attempts = append(attempts, validationAttempt{number: i, startedAt: startedAt, response: response})
+
+		if response.Result == "valid" {
+			break
+		} else {
+			time.Sleep(time.Duration(c.Interval) * time.Second)
+			i += 1
+		}
+	}
+
+	return
+}
+
+func repairIndex(c Config, sourceStore repair.SourceStore, indexStore IndexStore, rootNode string,
+	entries []remediation.Entry, log logger.Log) (err error) {
+
+	repairableIndexStore, ok := indexStore.(repair.IndexStore)
+	if !ok {
+		return errors.Wrap(errors.New("repair is not supported by the index backend"), 0)
+	}
+
+	audit := os.Stdout
+	if c.RepairAuditLog != "" && c.RepairAuditLog != "stdout" {
+		audit, err = os.OpenFile(c.RepairAuditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		defer audit.Close()
+	}
+
+	repairer := repair.Repairer{
+		SourceStore:   sourceStore,
+		IndexStore:    repairableIndexStore,
+		Method:        repair.Method(c.RepairMethod),
+		TouchSQL:      c.RepairTouchSQL,
+		DryRun:        c.RepairDryRun,
+		RatePerSecond: c.RepairRateLimit,
+		BatchSize:     c.RepairBatchSize,
+		Audit:         audit,
+		RootNode:      rootNode,
+		Log:           log,
+	}
+	_, err = repairer.Repair(entries)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	return
+}
+
+// newRuns builds a history run for each attempt of the validation of an index
+func newRuns(attempts []validationAttempt, index string, table string) (runs []history.Run) {
+	for _, attempt := range attempts {
+		run := history.NewRun(attempt.response, index, table, attempt.startedAt)
+		run.Attempt = attempt.number
+		run.Invocation = attempts[0].startedAt
+		runs = append(runs, run)
+	}
+	return
+}
+
+// saveHistory saves the runs after setting their persistent mismatches, then prunes the runs beyond retentionRuns
+func saveHistory(store *history.Store, runs []history.Run, persistentRuns int, retentionRuns int,
+	maxMismatches int) (saved []history.Run, err error) {
+	for _, run := range runs {
+		persistent, err := store.PersistentMismatches(run, persistentRuns)
+		if err != nil {
+			return saved, errors.Wrap(err, 0)
+		}
+		run.Details.Persistent = &persistent
+
+		run, err = store.Save(run, maxMismatches)
+		if err != nil {
+			return saved, errors.Wrap(err, 0)
+		}
+		saved = append(saved, run)
+	}
+
+	_, err = store.Prune(retentionRuns)
+	if err != nil {
+		return saved, errors.Wrap(err, 0)
+	}
+	return saved, nil
+}
+
+// printHistory prints the persisted validation runs as NDJSON, newest first
+func printHistory(c Config, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	index := flags.String("index", "", "only list runs of this index")
+	limit := flags.Int("limit", 20, "maximum number of runs to list, 0 lists every run")
+	path := flags.String("path", c.HistoryPath, "path to the history file, defaults to HISTORY_PATH")
+	err := flags.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	if *path == "" {
+		return errors.Wrap(errors.New("HISTORY_PATH is not set"), 0)
+	}
+
+	store, err := history.Open(*path)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+	defer store.Close()
+
+	runs, err := store.List(*index, *limit)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, run := range runs {
+		err = encoder.Encode(run)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+	}
+	return nil
+}
+
+// lintSchema prints each problem of the full avro schema, returning an error when there is any
+func lintSchema(c Config, args []string) error {
+	flags := flag.NewFlagSet("lint-schema", flag.ContinueOnError)
+	file := flags.String("file", "", "path to the full avro schema, defaults to FULL_AVRO_SCHEMA")
+	windowField := flags.String("window-field", c.CountWindowField, "timestamp field of the count windows, defaults to COUNT_WINDOW_FIELD")
+	err := flags.Parse(args)
+	if err != nil {
+		return errors.Wrap(err, 0)
+	}
+
+	schema := c.FullAvroSchema
+	if *file != "" {
+		content, err := os.ReadFile(*file)
+		if err != nil {
+			return errors.Wrap(err, 0)
+		}
+		schema = string(content)
+	}
+
+	schemaParser := avro.SchemaParser{FullSchemaString: schema}
+	lintErrors := schemaParser.Lint(*windowField)
+	for _, lintError := range lintErrors {
+		fmt.Println(lintError.Error())
+	}
+	if len(lintErrors) > 0 {
+		return errors.Wrap(fmt.Errorf("found %d problems in the full avro schema", len(lintErrors)), 0)
+	}
+	return nil
+}
+
 // currently assumes a single reference
 func main() {
 	start := time.Now()
@@ -88,8 +424,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	log.Info("Starting validation...")
-
 	//load config
 	var c Config
 	if strings.ToLower(os.Getenv("ENV")) == "development" {
@@ -106,6 +440,26 @@ func main() {
 		}
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		err = printHistory(c, os.Args[2:])
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "error listing validation history")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "lint-schema" {
+		err = lintSchema(c, os.Args[2:])
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "invalid full avro schema")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	log.Info("Starting validation...")
+
 	//parse avro schema
 	schemaParser := avro.SchemaParser{
 		FullSchemaString: c.FullAvroSchema,
@@ -116,6 +470,46 @@ func main() {
 		os.Exit(1)
 	}
 
+	rowFilter, err := filter.Parse(c.RowFilter)
+	if err != nil {
+		log.Error(errors.Wrap(err, 0), "error parsing ROW_FILTER")
+		os.Exit(1)
+	}
+
+	if c.RepairMethod != "" && repair.Method(c.RepairMethod) != repair.MethodTouch &&
+		repair.Method(c.RepairMethod) != repair.MethodIndex {
+		log.Error(errors.Wrap(errors.New("invalid REPAIR_METHOD value: "+c.RepairMethod+". Expected touch or index"), 0),
+			"invalid REPAIR_METHOD")
+		os.Exit(1)
+	}
+
+	if (c.ContentHashFirstPass || c.MerkleComparison || c.ContentHashSQL != "" || c.ContentHashField != "") &&
+		(c.ContentHashSQL == "" || c.ContentHashField == "") {
+		log.Error(errors.Wrap(errors.New(
+			"CONTENT_HASH_SQL and CONTENT_HASH_FIELD must be set together, they are required by CONTENT_HASH_FIRST_PASS and MERKLE_COMPARISON"), 0),
+			"invalid content hash configuration")
+		os.Exit(1)
+	}
+
+	if c.CountWindowField != "" && !filter.IsField(c.CountWindowField) {
+		log.Error(errors.Wrap(errors.New("invalid COUNT_WINDOW_FIELD value: "+c.CountWindowField+
+			". Expected a column name of letters, digits and underscores"), 0), "invalid COUNT_WINDOW_FIELD")
+		os.Exit(1)
+	}
+
+	if c.TenantColumn != "" && !filter.IsField(c.TenantColumn) {
+		log.Error(errors.Wrap(errors.New("invalid TENANT_COLUMN value: "+c.TenantColumn+
+			". Expected a column name of letters, digits and underscores"), 0), "invalid TENANT_COLUMN")
+		os.Exit(1)
+	}
+
+	if c.MerkleComparison && !parsedSchema.PrimaryKeyIsUUID() {
+		log.Error(errors.Wrap(errors.New(
+			"MERKLE_COMPARISON requires a UUID primary key, set the uuid logicalType on the type of the id field"), 0),
+			"invalid MERKLE_COMPARISON")
+		os.Exit(1)
+	}
+
 	//connect to database
 	if len(strings.Split(parsedSchema.FullAvroSchema.Namespace, ".")) < 2 {
 		log.Error(errors.Wrap(errors.New(
@@ -131,6 +525,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	dialect, err := DialectByName(dbConnectionInfo.Dialect)
+	if err != nil {
+		log.Error(errors.Wrap(err, 0), "invalid database dialect")
+		os.Exit(1)
+	}
+
 	dbClient, err := NewDBClient(DBParams{
 		User:             dbConnectionInfo.Username,
 		Password:         dbConnectionInfo.Password,
@@ -139,6 +539,16 @@ func main() {
 		Port:             dbConnectionInfo.Port,
 		Table:            dbConnectionInfo.Table,
 		SSLMode:          dbConnectionInfo.SSLMode,
+		Dialect:          dialect,
+		ReplicaHost:      dbConnectionInfo.ReplicaHostname,
+		ReplicaPort:      dbConnectionInfo.ReplicaPort,
+		MaxOpenConns:     c.DBMaxOpenConns,
+		MaxIdleConns:     c.DBMaxIdleConns,
+		ConnMaxLifetime:  time.Duration(c.DBConnMaxLifetimeSec) * time.Second,
+		StatementTimeout: time.Duration(c.DBStatementTimeoutSec) * time.Second,
+		ApplicationName:  c.DBApplicationName,
+		ContentHashSQL:   c.ContentHashSQL,
+		Filter:           rowFilter,
 		ParsedAvroSchema: parsedSchema,
 		Log:              log,
 	})
@@ -147,61 +557,113 @@ func main() {
 		os.Exit(1)
 	}
 
-	//connect to Elasticsearch
-	esClient, err := NewESClient(ESParams{
-		Url:              c.ElasticsearchHostUrl,
-		Username:         c.ElasticsearchUsername,
-		Password:         c.ElasticsearchPassword,
-		Index:            c.ElasticsearchIndex,
-		RootNode:         parsedSchema.RootNode,
-		ParsedAvroSchema: parsedSchema,
-		Log:              log,
-	})
+	//connect to Elasticsearch or OpenSearch
+	indexStore, err := newIndexStore(c, c.ElasticsearchIndex, parsedSchema, rowFilter, log)
 	if err != nil {
-		log.Error(errors.Wrap(err, 0), "error connecting to elasticsearch")
+		log.Error(errors.Wrap(err, 0), "error connecting to index backend", "backend", c.IndexBackend)
 		os.Exit(1)
 	}
 
-	//run validation
-	//TODO: auto retry if sync is progressing (i.e. new mismatch count < previous mismatch count)
-	i := 0
-	var jsonResponse []byte
-	for i < c.NumAttempts {
-		log.Info("Validation attempt", "number", i)
-		validator := Validator{
-			DBClient:                   *dbClient,
-			ESClient:                   *esClient,
-			PeriodMin:                  c.PeriodMin,
-			LagCompSec:                 c.LagCompSec,
-			Now:                        time.Now().UTC(),
-			Log:                        log,
-			InvalidThresholdPercentage: c.InvalidThresholdPercentage,
-			RootNode:                   parsedSchema.RootNode,
-			ValidateEverything:         c.ValidateEverything,
-			ContentChunkSize:           c.ContentChunkSize,
-			ContentMaxThreads:          c.ContentMaxThreads,
+	//point in time is only implemented for the Elasticsearch 7 client
+	if _, ok := indexStore.(PointInTimeIndexStore); c.ConsistencyMode && !ok {
+		log.Error(errors.Wrap(errors.New(
+			"CONSISTENCY_MODE requires ELASTICSEARCH_VERSION 7, point in time is not supported for Elasticsearch 8 or OpenSearch"), 0),
+			"invalid CONSISTENCY_MODE", "backend", c.IndexBackend)
+		os.Exit(1)
+	}
+
+	//deleting and writing documents is only implemented for the Elasticsearch 7 client
+	if _, ok := indexStore.(repair.IndexStore); c.RepairMethod != "" && !ok {
+		log.Error(errors.Wrap(errors.New(
+			"REPAIR_METHOD requires ELASTICSEARCH_VERSION 7, repair is not supported for Elasticsearch 8 or OpenSearch"), 0),
+			"invalid REPAIR_METHOD", "backend", c.IndexBackend)
+		os.Exit(1)
+	}
+
+	//the history file also stores the incremental validation checkpoints
+	var historyStore *history.Store
+	var checkpointStore CheckpointStore
+	if c.HistoryPath != "" {
+		historyStore, err = history.Open(c.HistoryPath)
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "unable to open validation history", "path", c.HistoryPath)
+			os.Exit(1)
 		}
-		response, err := validator.Validate()
+		checkpointStore = historyStore
+	}
+
+	//run validation
+	response, attempts, err := validate(c, dbClient, indexStore, c.ElasticsearchIndex, parsedSchema, checkpointStore, log)
+	if err != nil {
+		log.Error(errors.Wrap(err, 0), "error during validation")
+		os.Exit(1)
+	}
 
+	runs := newRuns(attempts, c.ElasticsearchIndex, dbConnectionInfo.Table)
+	activeRuns := len(runs)
+	remediationEntries := remediation.Entries(response.Mismatches, c.ElasticsearchIndex, dbConnectionInfo.Table)
+
+	//validate the refreshing index side by side with the active index to gate the alias swap
+	if c.ElasticsearchRefreshIndex != "" {
+		refreshingIndexStore, err := newIndexStore(c, c.ElasticsearchRefreshIndex, parsedSchema, rowFilter, log)
 		if err != nil {
-			log.Error(errors.Wrap(err, 0), "error during validation")
+			log.Error(errors.Wrap(err, 0), "error connecting to refreshing index", "index", c.ElasticsearchRefreshIndex)
 			os.Exit(1)
 		}
 
-		jsonResponse, err = json.Marshal(response)
+		refreshingResponse, refreshingAttempts, err := validate(c, dbClient, refreshingIndexStore, c.ElasticsearchRefreshIndex, parsedSchema, checkpointStore, log)
 		if err != nil {
-			log.Error(errors.Wrap(err, 0), "unable to marshal response to JSON")
+			log.Error(errors.Wrap(err, 0), "error during validation of refreshing index")
 			os.Exit(1)
 		}
 
-		if response.Result == "valid" {
-			break
-		} else {
-			time.Sleep(time.Duration(c.Interval) * time.Second)
-			i += 1
+		comparison := CompareIndices(response, refreshingResponse)
+		response.Details.IndexComparison = &comparison
+		runs = append(runs, newRuns(refreshingAttempts, c.ElasticsearchRefreshIndex, dbConnectionInfo.Table)...)
+		remediationEntries = append(remediationEntries,
+			remediation.Entries(refreshingResponse.Mismatches, c.ElasticsearchRefreshIndex, dbConnectionInfo.Table)...)
+	}
+
+	if historyStore != nil {
+		savedRuns, err := saveHistory(historyStore, runs, c.PersistentMismatchRuns, c.HistoryRetentionRuns,
+			c.HistoryMaxMismatches)
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "unable to save validation history", "path", c.HistoryPath)
+		} else if activeRuns > 0 {
+			//the persistent mismatches are reported for the last attempt of the active index
+			persistent := savedRuns[activeRuns-1].Details.Persistent
+			response.Details.Persistent = persistent
+			metrics.ObserveMismatches(persistent.Count, persistent.TransientCount)
+		}
+
+		err = historyStore.Close()
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "unable to close validation history", "path", c.HistoryPath)
 		}
 	}
 
+	if c.RemediationOutput != "" && len(remediationEntries) > 0 {
+		err = remediation.Write(c.RemediationOutput, remediationEntries)
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "unable to write remediation list", "output", c.RemediationOutput)
+		}
+	}
+
+	//repair mode is opt-in, only the active index is repaired
+	if c.RepairMethod != "" && response.Result == validation.ValidationInvalid {
+		err = repairIndex(c, dbClient, indexStore, parsedSchema.RootNode,
+			remediation.Entries(response.Mismatches, c.ElasticsearchIndex, dbConnectionInfo.Table), log)
+		if err != nil {
+			log.Error(errors.Wrap(err, 0), "error during repair")
+		}
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		log.Error(errors.Wrap(err, 0), "unable to marshal response to JSON")
+		os.Exit(1)
+	}
+
 	err = metrics.Push(c.PrometheusPushGatewayUrl, c.ElasticsearchIndex)
 	if err != nil {
 		log.Error(errors.Wrap(err, 0), "unable to push metrics")